@@ -0,0 +1,255 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
+)
+
+// batchImport refreshes, converts and writes resources of a type in batches of options.BatchSize,
+// so only discovered resources and one batch per type are held in memory
+type batchImport struct {
+	provider        terraformutils.ProviderGenerator
+	options         ImportOptions
+	providerWrapper *providerwrapper.ProviderWrapper
+	report          *terraformutils.ImportReport
+	writers         map[string]*terraformoutput.BatchWriter
+}
+
+// validateBatchOptions rejects options which need all resources at once
+func validateBatchOptions(options ImportOptions) error {
+	unsupported := map[string]bool{
+		"plan":                 options.Plan,
+		"--output=json":        options.Output == "json",
+		"--terragrunt":         options.Terragrunt,
+		"--state=bucket":       options.State == "bucket",
+		"--graph":              options.Graph != "",
+		"--previous-state":     options.PreviousState != "",
+		"--validate-resources": options.Validate,
+		"--verify":             options.Verify,
+		"--as-data-source":     len(options.AsDataSource) > 0,
+		"--git-repo":           options.GitRepo != "",
+		"--provider-aliases":   options.ProviderAliases,
+		"resource placeholders in --path-pattern": hasResourcePlaceholders(options.PathPattern),
+	}
+	var names []string
+	for name, set := range unsupported {
+		if set {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("--batch-size can't be used with %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// importInBatches imports service after service, every page of services discovering page by page
+// goes into the batches before the next page is discovered
+func importInBatches(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) error {
+	if err := validateBatchOptions(options); err != nil {
+		return err
+	}
+	providerWrapper, options, err := initOptionsAndWrapper(provider, options, args)
+	if err != nil {
+		return err
+	}
+	defer providerWrapper.Kill()
+	if options.Connect {
+		log.Println(provider.GetName() + " resources of batches aren't connected")
+	}
+
+	b := &batchImport{
+		provider:        provider,
+		options:         options,
+		providerWrapper: providerWrapper,
+		report:          terraformutils.NewImportReport(),
+		writers:         map[string]*terraformoutput.BatchWriter{},
+	}
+	err = b.importServices(args)
+	for _, writer := range b.writers {
+		if e := writer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !b.report.IsEmpty() {
+		return ExportReportFile(b.report, terraformerPath(provider, options), "report.json")
+	}
+	return nil
+}
+
+func (b *batchImport) importServices(args []string) error {
+	for _, service := range b.options.Resources {
+		serviceProvider := terraformutils.NewProvidersMapping(b.provider).AddServiceToProvider(service)
+		if err := serviceProvider.Init(args); err != nil {
+			return err
+		}
+		writer, err := b.writer(service)
+		if err != nil {
+			return err
+		}
+		if err := b.importService(service, serviceProvider, writer); err != nil {
+			log.Printf("%s error importing %s, err: %s\n", b.provider.GetName(), service, err)
+		}
+	}
+	return nil
+}
+
+// writer returns the writer of the directory of the service, services share it without {service} in the path pattern
+func (b *batchImport) writer(service string) (*terraformoutput.BatchWriter, error) {
+	serviceName := service
+	if !strings.Contains(b.options.PathPattern, "{service}") {
+		serviceName = ""
+	}
+	path := PathWithAttributes(b.options.PathPattern, b.provider.GetName(), serviceName, b.options.PathOutput, providerPathAttributes(b.provider, b.options.PathPattern))
+	if writer, exist := b.writers[path]; exist {
+		return writer, nil
+	}
+	writer, err := terraformoutput.NewBatchWriter(b.provider, path, serviceName, b.options.Compact, b.options.Documents, stateProviderSources(b.provider), stateLineage(b.options, path))
+	if err != nil {
+		return nil, err
+	}
+	b.writers[path] = writer
+	return writer, nil
+}
+
+func (b *batchImport) importService(service string, provider terraformutils.ProviderGenerator, writer *terraformoutput.BatchWriter) error {
+	log.Println(provider.GetName() + " importing... " + service)
+	if err := provider.InitService(service, b.options.Verbose); err != nil {
+		return err
+	}
+	serviceGenerator := provider.GetService()
+	serviceGenerator.ParseFilters(b.options.Filter)
+
+	discovered := func(resources []terraformutils.Resource) []terraformutils.Resource {
+		serviceGenerator.SetResources(resources)
+		cleanupServiceResources(serviceGenerator, b.options, b.report)
+		resources = serviceGenerator.GetResources()
+		terraformutils.ReportProgress(terraformutils.ProgressDiscovered, provider.GetName(), service, len(resources))
+		return resources
+	}
+
+	importBatches := func(batches [][]terraformutils.Resource) error {
+		for _, batch := range batches {
+			if err := b.importBatch(serviceGenerator, batch, writer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := terraformutils.ErrNoBatches
+	if batchService, ok := serviceGenerator.(terraformutils.BatchService); ok {
+		err = batchService.InitResourcesInBatches(func(resources []terraformutils.Resource) error {
+			return importBatches(pageBatches(discovered(resources), b.options.BatchSize))
+		})
+	}
+	if err == terraformutils.ErrNoBatches {
+		// services without pages are discovered at once, only refreshing is done in batches
+		if err = serviceGenerator.InitResources(); err != nil {
+			return err
+		}
+		err = importBatches(batchesByType(discovered(serviceGenerator.GetResources()), b.options.BatchSize))
+	}
+	if err != nil {
+		return err
+	}
+	log.Println(provider.GetName() + " done importing " + service)
+	return nil
+}
+
+// pageBatches imports a page fitting into a batch as a whole, so hooks see related resources of the
+// page together, like a zone and its records. Larger pages are split into batches of resources of a type.
+func pageBatches(resources []terraformutils.Resource, size int) [][]terraformutils.Resource {
+	if len(resources) == 0 {
+		return nil
+	}
+	if len(resources) <= size {
+		return [][]terraformutils.Resource{resources}
+	}
+	return batchesByType(resources, size)
+}
+
+// batchesByType splits resources into batches of size resources of the same type, types in order of discovery
+func batchesByType(resources []terraformutils.Resource, size int) [][]terraformutils.Resource {
+	var types []string
+	byType := map[string][]terraformutils.Resource{}
+	for _, r := range resources {
+		if _, exist := byType[r.InstanceInfo.Type]; !exist {
+			types = append(types, r.InstanceInfo.Type)
+		}
+		byType[r.InstanceInfo.Type] = append(byType[r.InstanceInfo.Type], r)
+	}
+	var batches [][]terraformutils.Resource
+	for _, resourceType := range types {
+		ofType := byType[resourceType]
+		for len(ofType) > size {
+			batches = append(batches, ofType[:size])
+			ofType = ofType[size:]
+		}
+		batches = append(batches, ofType)
+	}
+	return batches
+}
+
+// importBatch refreshes and converts the resources, cleans them up with the hooks of the service and
+// writes them. The hooks only see the resources of the batch, a page of batch services or resources of a type.
+func (b *batchImport) importBatch(service terraformutils.ServiceGenerator, resources []terraformutils.Resource, writer *terraformoutput.BatchWriter) error {
+	service.SetResources(resources)
+	service.PopulateIgnoreKeys(b.providerWrapper)
+	resources = service.GetResources()
+	var regular, slow []*terraformutils.Resource
+	for i := range resources {
+		if resources[i].SlowQueryRequired {
+			slow = append(slow, &resources[i])
+		} else {
+			regular = append(regular, &resources[i])
+		}
+	}
+	refreshed, err := terraformutils.RefreshResources(regular, b.providerWrapper, [][]*terraformutils.Resource{slow})
+	if err != nil {
+		return err
+	}
+
+	converted := []terraformutils.Resource{}
+	for _, r := range refreshed {
+		if err := r.ConvertTFstate(b.providerWrapper); err != nil {
+			log.Printf("failed to convert resources %s because of error %s", r.InstanceInfo.Id, err)
+		}
+		converted = append(converted, *r)
+	}
+	service.SetResources(converted)
+	service.PostRefreshCleanup()
+	if err := service.PostConvertHook(); err != nil {
+		log.Printf("failed run PostConvertHook because of error %s", err)
+	}
+	converted = service.GetResources()
+	// most tags are only known after refresh
+	if !b.options.IncludeManaged {
+		converted = terraformutils.ExcludeManagedBy(converted, b.options.managedByRules, b.report)
+	}
+	service.SetResources(nil)
+	return writer.Write(converted)
+}
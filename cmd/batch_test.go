@@ -0,0 +1,80 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func TestPageBatches(t *testing.T) {
+	var page []terraformutils.Resource
+	for _, r := range []struct{ id, resourceType string }{
+		{"Z1", "aws_route53_zone"},
+		{"www", "aws_route53_record"},
+		{"api", "aws_route53_record"},
+		{"db", "aws_route53_record"},
+	} {
+		page = append(page, terraformutils.NewSimpleResource(r.id, r.id, r.resourceType, "aws", []string{}))
+	}
+	ids := func(batches [][]terraformutils.Resource) [][]string {
+		var result [][]string
+		for _, batch := range batches {
+			var batchIDs []string
+			for _, r := range batch {
+				batchIDs = append(batchIDs, r.InstanceState.ID)
+			}
+			result = append(result, batchIDs)
+		}
+		return result
+	}
+	for _, test := range []struct {
+		size     int
+		expected [][]string
+	}{
+		{4, [][]string{{"Z1", "www", "api", "db"}}},
+		{10, [][]string{{"Z1", "www", "api", "db"}}},
+		{2, [][]string{{"Z1"}, {"www", "api"}, {"db"}}},
+	} {
+		if batches := ids(pageBatches(page, test.size)); !reflect.DeepEqual(batches, test.expected) {
+			t.Errorf("expected batches %v of size %d, got %v", test.expected, test.size, batches)
+		}
+	}
+	if batches := pageBatches(nil, 2); len(batches) != 0 {
+		t.Errorf("expected no batches of an empty page, got %v", batches)
+	}
+}
+
+func TestNewImportPlanRejectsBatchSize(t *testing.T) {
+	for _, test := range []struct {
+		plan     bool
+		expected string
+	}{
+		{false, "--batch-size can't be used with --provider-aliases"},
+		{true, "--batch-size can't be used with --provider-aliases, plan"},
+	} {
+		options := NewImportOptions()
+		options.BatchSize = 100
+		options.ProviderAliases = true
+		options.Plan = test.plan
+		_, err := newImportPlan(nil, options, nil)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected error %q, got %v", test.expected, err)
+		}
+	}
+}
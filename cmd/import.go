@@ -14,10 +14,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -54,6 +56,40 @@ type ImportOptions struct {
 	Output        string
 	RetryCount    int
 	RetrySleepMs  int
+	Terragrunt    bool
+	PreviousState string
+	KeepNames     bool
+	Validate      bool
+	Verify        bool
+	Documents     string
+	Graph         string
+	GraphFormat   string
+	AsDataSource  []string
+	GitRepo       string
+	GitBranch     string
+	GitCommit     bool
+	RegistryHost  string
+	// ExcludeManaged are state files or directories, resources in them aren't imported
+	ExcludeManaged []string
+	// IncludeManaged keeps resources owned by other controllers, like CloudFormation stacks
+	IncludeManaged bool
+	// ManagedByTags are key or key=regexp of tags and labels of resources owned by other controllers
+	ManagedByTags []string
+	// Deterministic freezes the lineage of states, so unchanged imports write the same files
+	Deterministic bool
+	// BatchSize imports resources of a type in batches of this size, pages of services discovering
+	// page by page are batches of their own when they fit, 0 imports all at once
+	BatchSize int
+
+	// RegionParallelism is the number of regions or projects imported at once
+	RegionParallelism int `json:"-"`
+	// ProviderAliases imports all regions, projects or subscriptions into the same directories
+	ProviderAliases bool `json:"-"`
+	// Inventory collects discovered resources instead of writing Terraform files
+	Inventory *InventoryOptions `json:"-"`
+
+	managedResources terraformutils.ManagedResources
+	managedByRules   []terraformutils.ManagedByRule
 }
 
 const DefaultPathPattern = "{output}/{provider}/{service}/"
@@ -72,6 +108,7 @@ func newImportCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newCmdPlanImporter(options))
+	cmd.AddCommand(newCmdGenericImporter(options))
 	for _, subcommand := range providerImporterSubcommands() {
 		providerCommand := subcommand(options)
 		_ = providerCommand.MarkPersistentFlagRequired("resources")
@@ -80,40 +117,137 @@ func newImportCmd() *cobra.Command {
 	return cmd
 }
 
+// NewImportOptions returns options with the defaults of the provider command flags
+func NewImportOptions() ImportOptions {
+	options := ImportOptions{}
+	baseProviderFlags(pflag.NewFlagSet("import", pflag.ContinueOnError), &options, "", "")
+	return options
+}
+
 func Import(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) error {
+	if options.Inventory != nil {
+		return Inventory(provider, options, args)
+	}
+	if options.BatchSize > 0 {
+		return importInBatches(provider, options, args)
+	}
+	plan, err := newImportPlan(provider, options, args)
+	if err != nil {
+		return err
+	}
+	return importFromPlan(provider, plan)
+}
 
+// newImportPlan discovers and refreshes resources, everything but writing them
+func newImportPlan(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) (*ImportPlan, error) {
+	// imports with --batch-size are written batch by batch, only provider aliases get here with it
+	if options.BatchSize > 0 {
+		if err := validateBatchOptions(options); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("--batch-size writes resources while they are imported, it can't be used with plan or --provider-aliases")
+	}
 	providerWrapper, options, err := initOptionsAndWrapper(provider, options, args)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer providerWrapper.Kill()
 	providerMapping := terraformutils.NewProvidersMapping(provider)
 
-	err = initAllServicesResources(providerMapping, options, args, providerWrapper)
+	report := terraformutils.NewImportReport()
+	err = initAllServicesResources(providerMapping, options, args, providerWrapper, report)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = terraformutils.RefreshResourcesByProvider(providerMapping, providerWrapper)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// most tags are only known after refresh
+	if !options.IncludeManaged {
+		providerMapping.ExcludeManagedBy(options.managedByRules, report)
 	}
 
 	providerMapping.ConvertTFStates(providerWrapper)
 	// change structs with additional data for each resource
 	providerMapping.CleanupProviders()
 
-	err = importFromPlan(providerMapping, options, args)
+	if len(options.AsDataSource) > 0 {
+		providerMapping.ConvertDataSources(providerWrapper, options.AsDataSource)
+	}
+
+	if options.Validate {
+		log.Println(provider.GetName() + " validating resources")
+		providerMapping.ValidateResources(providerWrapper, report)
+	}
+	if options.Verify {
+		log.Println(provider.GetName() + " verifying resources")
+		providerMapping.VerifyResources(providerWrapper, report)
+	}
 
-	return err
+	plan := &ImportPlan{
+		Provider:         provider.GetName(),
+		Options:          options,
+		Args:             args,
+		ImportedResource: map[string][]terraformutils.Resource{},
+		Report:           report,
+	}
+	resourcesByService := providerMapping.GetResourcesByService()
+	for service := range resourcesByService {
+		plan.ImportedResource[service] = append(plan.ImportedResource[service], resourcesByService[service]...)
+	}
+	return plan, nil
 }
 
 func initOptionsAndWrapper(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) (*providerwrapper.ProviderWrapper, ImportOptions, error) {
-	err := provider.Init(args)
+	options, err := initOptions(provider, options, args)
+	if err != nil {
+		return nil, options, err
+	}
+
+	providerWrapper, err := providerwrapper.NewProviderWrapper(provider.GetName(), provider.GetConfig(), options.Verbose, map[string]int{"retryCount": options.RetryCount, "retrySleepMs": options.RetrySleepMs})
 	if err != nil {
 		return nil, options, err
 	}
 
+	return providerWrapper, options, nil
+}
+
+// initOptions initializes the provider and expands filter files and the resources to import
+func initOptions(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) (ImportOptions, error) {
+	if err := terraformoutput.ValidateDocumentsMode(options.Documents); err != nil {
+		return options, err
+	}
+	if options.GraphFormat != "dot" && options.GraphFormat != "json" {
+		return options, fmt.Errorf("unknown graph format %s, use dot or json", options.GraphFormat)
+	}
+	filters, err := terraformutils.ExpandFilterFiles(options.Filter)
+	if err != nil {
+		return options, err
+	}
+	options.Filter = filters
+	if len(options.ExcludeManaged) > 0 {
+		if options.managedResources, err = terraformutils.ReadManagedResources(options.ExcludeManaged); err != nil {
+			return options, err
+		}
+	}
+	if !options.IncludeManaged {
+		rules, err := terraformutils.ParseManagedByTags(options.ManagedByTags)
+		if err != nil {
+			return options, err
+		}
+		options.managedByRules = append(append([]terraformutils.ManagedByRule{}, terraformutils.DefaultManagedByRules...), rules...)
+	}
+	providerwrapper.SetRegistryHost(options.RegistryHost)
+	err = provider.Init(args)
+	if err != nil {
+		return options, err
+	}
+	if err := validatePathPattern(provider, options.PathPattern); err != nil {
+		return options, err
+	}
+
 	if terraformerstring.ContainsString(options.Resources, "*") {
 		log.Println("Attempting an import of ALL resources in " + provider.GetName())
 		options.Resources = providerServices(provider)
@@ -136,15 +270,10 @@ func initOptionsAndWrapper(provider terraformutils.ProviderGenerator, options Im
 		options.Resources = localSlice
 	}
 
-	providerWrapper, err := providerwrapper.NewProviderWrapper(provider.GetName(), provider.GetConfig(), options.Verbose, map[string]int{"retryCount": options.RetryCount, "retrySleepMs": options.RetrySleepMs})
-	if err != nil {
-		return nil, options, err
-	}
-
-	return providerWrapper, options, nil
+	return options, nil
 }
 
-func initAllServicesResources(providersMapping *terraformutils.ProvidersMapping, options ImportOptions, args []string, providerWrapper *providerwrapper.ProviderWrapper) error {
+func initAllServicesResources(providersMapping *terraformutils.ProvidersMapping, options ImportOptions, args []string, providerWrapper *providerwrapper.ProviderWrapper, report *terraformutils.ImportReport) error {
 	numOfResources := len(options.Resources)
 	var wg sync.WaitGroup
 	wg.Add(numOfResources)
@@ -157,7 +286,7 @@ func initAllServicesResources(providersMapping *terraformutils.ProvidersMapping,
 		if err != nil {
 			return err
 		}
-		err = initServiceResources(service, serviceProvider, options, providerWrapper)
+		err = initServiceResources(service, serviceProvider, options, providerWrapper, report)
 		if err != nil {
 			failedServices = append(failedServices, service)
 		}
@@ -170,29 +299,59 @@ func initAllServicesResources(providersMapping *terraformutils.ProvidersMapping,
 	return nil
 }
 
-func importFromPlan(providerMapping *terraformutils.ProvidersMapping, options ImportOptions, args []string) error {
-	plan := &ImportPlan{
-		Provider:         providerMapping.GetBaseProvider().GetName(),
-		Options:          options,
-		Args:             args,
-		ImportedResource: map[string][]terraformutils.Resource{},
+func importFromPlan(provider terraformutils.ProviderGenerator, plan *ImportPlan) error {
+	options := plan.Options
+	if options.Plan {
+		if options.Graph != "" {
+			directories := outputDirectories(provider, options, plan.ImportedResource)
+			if err := printGraph(provider, options, plan.ImportedResource, directories); err != nil {
+				return err
+			}
+		}
+		return ExportPlanFile(plan, terraformerPath(provider, options), "plan.json")
 	}
 
-	resourcesByService := providerMapping.GetResourcesByService()
-	for service := range resourcesByService {
-		plan.ImportedResource[service] = append(plan.ImportedResource[service], resourcesByService[service]...)
-	}
+	return ImportFromPlan(provider, plan)
+}
 
-	if options.Plan {
-		path := Path(options.PathPattern, providerMapping.GetBaseProvider().GetName(), "terraformer", options.PathOutput)
-		return ExportPlanFile(plan, path, "plan.json")
+// importInParallel runs at most parallelism imports at once, like a sequential loop it doesn't start
+// imports after the first error and returns it
+func importInParallel(parallelism int, imports []func() error) error {
+	if parallelism < 1 {
+		parallelism = 1
 	}
-
-	return ImportFromPlan(providerMapping.GetBaseProvider(), plan)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	semaphore := make(chan struct{}, parallelism)
+	for _, importFunc := range imports {
+		semaphore <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-semaphore
+			break
+		}
+		wg.Add(1)
+		go func(importFunc func() error) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := importFunc(); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(importFunc)
+	}
+	wg.Wait()
+	return firstErr
 }
 
 func initServiceResources(service string, provider terraformutils.ProviderGenerator,
-	options ImportOptions, providerWrapper *providerwrapper.ProviderWrapper) error {
+	options ImportOptions, providerWrapper *providerwrapper.ProviderWrapper, report *terraformutils.ImportReport) error {
 	log.Println(provider.GetName() + " importing... " + service)
 	err := provider.InitService(service, options.Verbose)
 	if err != nil {
@@ -206,55 +365,259 @@ func initServiceResources(service string, provider terraformutils.ProviderGenera
 		return err
 	}
 
-	provider.GetService().PopulateIgnoreKeys(providerWrapper)
-	provider.GetService().InitialCleanup()
+	if providerWrapper != nil { // inventories without refresh run without provider plugin
+		provider.GetService().PopulateIgnoreKeys(providerWrapper)
+	}
+	cleanupServiceResources(provider.GetService(), options, report)
+	terraformutils.ReportProgress(terraformutils.ProgressDiscovered, provider.GetName(), service, len(provider.GetService().GetResources()))
 	log.Println(provider.GetName() + " done importing " + service)
 
 	return nil
 }
 
+// cleanupServiceResources runs the initial cleanup and drops managed resources, before the refresh,
+// which takes the most time
+func cleanupServiceResources(service terraformutils.ServiceGenerator, options ImportOptions, report *terraformutils.ImportReport) {
+	service.InitialCleanup()
+	if options.managedResources != nil {
+		service.SetResources(options.managedResources.Exclude(service.GetResources(), report))
+	}
+	if !options.IncludeManaged {
+		service.SetResources(terraformutils.ExcludeManagedBy(service.GetResources(), options.managedByRules, report))
+	}
+}
+
+var (
+	// gitMu serializes imports of regions running in parallel, each of them writes and commits on its own
+	gitMu sync.Mutex
+	// gitRepos are checked for uncommitted changes once, before the first region writes into them
+	gitRepos = map[string]*terraformoutput.GitRepo{}
+)
+
 func ImportFromPlan(provider terraformutils.ProviderGenerator, plan *ImportPlan) error {
 	options := plan.Options
 	importedResource := plan.ImportedResource
-	isServicePath := strings.Contains(options.PathPattern, "{service}")
+	providerwrapper.SetRegistryHost(options.RegistryHost)
+
+	var repo *terraformoutput.GitRepo
+	if options.GitRepo != "" {
+		gitMu.Lock()
+		defer gitMu.Unlock()
+		var err error
+		if repo, err = openGitRepo(options); err != nil {
+			return err
+		}
+		if !filepath.IsAbs(options.PathOutput) {
+			options.PathOutput = filepath.Join(repo.Path, options.PathOutput)
+		}
+	}
+
+	var previousNames terraformoutput.PreviousNames
+	if options.PreviousState != "" {
+		previous, err := terraformutils.ReadStateResources(options.PreviousState)
+		if err != nil {
+			return err
+		}
+		previousNames = terraformoutput.NewPreviousNames(previous)
+		if options.KeepNames {
+			for service := range importedResource {
+				previousNames.KeepPreviousNames(importedResource[service])
+			}
+		}
+	}
+
+	directories := outputDirectories(provider, options, importedResource)
+	if len(directories) == 0 {
+		return nil
+	}
+	directoriesByName := map[string]*outputDirectory{}
+	for _, directory := range directories {
+		directoriesByName[directory.Name] = directory
+		if previousNames != nil && !options.KeepNames {
+			directory.Moved = previousNames.MovedBlocks(directory.Resources)
+		}
+	}
+
+	// the graph is built from connected values, so it goes before they are replaced by references
+	if options.Graph != "" {
+		if err := printGraph(provider, options, importedResource, directories); err != nil {
+			return err
+		}
+	}
 
 	if options.Connect {
 		log.Println(provider.GetName() + " Connecting.... ")
-		importedResource = terraformutils.ConnectServices(importedResource, isServicePath, provider.GetResourceConnections())
+		reference := terraformutils.RemoteStateReference
+		if options.Terragrunt {
+			reference = terraformoutput.TerragruntReference
+		}
+		if directories[0].Name == "" {
+			terraformutils.ConnectServicesWithReference(importedResource, false, provider.GetResourceConnections(), reference)
+		} else {
+			resources := map[string][]terraformutils.Resource{}
+			connections := map[string]map[string][]string{}
+			for _, directory := range directories {
+				resources[directory.Name] = directory.Resources
+				connections[directory.Name] = directory.Connections
+			}
+			terraformutils.ConnectServicesWithReference(resources, true, connections, reference)
+			// with the data sources they reference
+			for _, directory := range directories {
+				directory.Resources = resources[directory.Name]
+			}
+		}
 	}
 
-	if !isServicePath {
-		var compactedResources []terraformutils.Resource
-		for _, resources := range importedResource {
-			compactedResources = append(compactedResources, resources...)
+	if options.Terragrunt && directories[0].Name != "" {
+		if err := printTerragruntRoot(provider, options); err != nil {
+			return err
 		}
-		e := printService(provider, "", options, compactedResources, importedResource)
-		if e != nil {
+	}
+
+	summaries := map[string]terraformoutput.ChangeSummary{}
+	for _, directory := range directories {
+		if repo != nil && options.State != "bucket" {
+			var previous []terraformutils.StateResource
+			if _, err := os.Stat(directory.Path + "/terraform.tfstate"); err == nil {
+				if previous, err = terraformutils.ReadStateResources(directory.Path + "/terraform.tfstate"); err != nil {
+					return err
+				}
+			}
+			name := directory.Name
+			if name == "" {
+				name = provider.GetName()
+			}
+			summaries[name] = terraformoutput.SummarizeChanges(previous, directory.Resources)
+		}
+		if e := printService(provider, directory, options, directoriesByName); e != nil {
 			return e
 		}
-	} else {
-		for serviceName, resources := range importedResource {
-			e := printService(provider, serviceName, options, resources, importedResource)
-			if e != nil {
-				return e
-			}
+	}
+	if plan.Report != nil && !plan.Report.IsEmpty() {
+		if err := ExportReportFile(plan.Report, terraformerPath(provider, options), "report.json"); err != nil {
+			return err
 		}
 	}
+	if repo != nil && options.GitCommit {
+		return commitOutput(repo, provider, options, summaries)
+	}
 	return nil
 }
 
-func printService(provider terraformutils.ProviderGenerator, serviceName string, options ImportOptions, resources []terraformutils.Resource, importedResource map[string][]terraformutils.Resource) error {
-	log.Println(provider.GetName() + " save " + serviceName)
+func openGitRepo(options ImportOptions) (*terraformoutput.GitRepo, error) {
+	key := options.GitRepo + "@" + options.GitBranch
+	if repo, exist := gitRepos[key]; exist {
+		return repo, nil
+	}
+	repo, err := terraformoutput.OpenGitRepo(options.GitRepo)
+	if err != nil {
+		return nil, err
+	}
+	if options.GitBranch != "" {
+		log.Println("git checkout " + options.GitBranch)
+		if err := repo.Checkout(options.GitBranch); err != nil {
+			return nil, err
+		}
+	}
+	gitRepos[key] = repo
+	return repo, nil
+}
+
+// commitOutput commits the files below the root of the path pattern, the working tree was clean
+// before the import, so only generated files change there
+func commitOutput(repo *terraformoutput.GitRepo, provider terraformutils.ProviderGenerator, options ImportOptions, summaries map[string]terraformoutput.ChangeSummary) error {
+	root := PathWithAttributes(rootPathPattern(options.PathPattern), provider.GetName(), "", options.PathOutput, providerPathAttributes(provider, options.PathPattern))
+	subject := "terraformer import " + provider.GetName()
+	if rel, err := filepath.Rel(repo.Path, root); err == nil {
+		subject += " into " + filepath.ToSlash(filepath.Clean(rel))
+	}
+	message := subject + "\n"
+	if len(summaries) > 0 {
+		message = terraformoutput.GitCommitMessage(subject, summaries)
+	}
+	committed, err := repo.Commit([]string{root}, message)
+	if err != nil {
+		return err
+	}
+	if committed {
+		log.Println("git commit: " + subject)
+	} else {
+		log.Println("git: nothing changed in " + root)
+	}
+	return nil
+}
+
+func outputDirectories(provider terraformutils.ProviderGenerator, options ImportOptions, importedResource map[string][]terraformutils.Resource) []*outputDirectory {
+	if hasResourcePlaceholders(options.PathPattern) {
+		return resourceDirectories(provider, options, importedResource)
+	}
+	return serviceDirectories(provider, options, importedResource)
+}
+
+func printGraph(provider terraformutils.ProviderGenerator, options ImportOptions, importedResource map[string][]terraformutils.Resource, directories []*outputDirectory) error {
+	directoryByResource := map[string]string{}
+	for _, directory := range directories {
+		for _, r := range directory.Resources {
+			directoryByResource[r.InstanceInfo.Id] = directory.Path
+		}
+	}
+	graph := terraformutils.NewGraph(importedResource, provider.GetResourceConnections(), func(service string, r terraformutils.Resource) string {
+		return directoryByResource[r.InstanceInfo.Id]
+	})
+	var data []byte
+	if options.GraphFormat == "json" {
+		var err error
+		if data, err = graph.JSON(); err != nil {
+			return err
+		}
+	} else {
+		data = graph.DOT()
+	}
+	log.Println(provider.GetName() + " save graph to " + options.Graph)
+	if dir := filepath.Dir(options.Graph); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(options.Graph, data, os.ModePerm)
+}
+
+func printService(provider terraformutils.ProviderGenerator, directory *outputDirectory, options ImportOptions, directories map[string]*outputDirectory) error {
+	serviceName := directory.ServiceName
+	resources := directory.Resources
+	log.Println(provider.GetName() + " save " + directory.Name)
 	// Print HCL files for Resources
-	path := Path(options.PathPattern, provider.GetName(), serviceName, options.PathOutput)
-	err := terraformoutput.OutputHclFiles(resources, provider, path, serviceName, options.Compact, options.Output)
+	path := directory.Path
+	var err error
+	if options.Terragrunt && directory.Name != "" {
+		err = terraformoutput.OutputResourceFiles(resources, provider, path, serviceName, options.Compact, options.Output, options.Documents)
+	} else {
+		err = terraformoutput.OutputHclFiles(resources, provider, path, serviceName, options.Compact, options.Output, options.Documents)
+	}
 	if err != nil {
 		return err
 	}
-	tfStateFile, err := terraformutils.PrintTfState(resources)
+	if err := terraformoutput.OutputMovedFile(directory.Moved, path, options.Output); err != nil {
+		return err
+	}
+	tfStateFile, err := printTfState(provider, resources, stateLineage(options, path))
 	if err != nil {
 		return err
 	}
+	if options.Terragrunt {
+		if options.State == "bucket" {
+			log.Println(provider.GetName() + " upload tfstate to  bucket " + options.Bucket)
+			bucket := terraformoutput.BucketState{
+				Name: options.Bucket,
+			}
+			if err := bucket.BucketUpload(path, tfStateFile); err != nil {
+				return err
+			}
+		} else if err := ioutil.WriteFile(path+"/terraform.tfstate", tfStateFile, os.ModePerm); err != nil {
+			return err
+		}
+		return printTerragruntService(provider, directory, options, directories)
+	}
 	// print or upload State file
 	if options.State == "bucket" {
 		log.Println(provider.GetName() + " upload tfstate to  bucket " + options.Bucket)
@@ -269,18 +632,18 @@ func printService(provider terraformutils.ProviderGenerator, serviceName string,
 			terraformoutput.PrintFile(path+"/bucket.tf", bucketStateDataFile)
 		}
 	} else {
-		if serviceName == "" {
+		if directory.Name == "" {
 			log.Println(provider.GetName() + " save tfstate")
 		} else {
-			log.Println(provider.GetName() + " save tfstate for " + serviceName)
+			log.Println(provider.GetName() + " save tfstate for " + directory.Name)
 		}
 		if err := ioutil.WriteFile(path+"/terraform.tfstate", tfStateFile, os.ModePerm); err != nil {
 			return err
 		}
 	}
 	// Print hcl variables.tf
-	if serviceName != "" {
-		if options.Connect && len(provider.GetResourceConnections()[serviceName]) > 0 {
+	if directory.Name != "" {
+		if options.Connect && len(directory.Connections) > 0 {
 			variables := map[string]map[string]map[string]interface{}{}
 			variables["data"] = map[string]map[string]interface{}{}
 			variables["data"]["terraform_remote_state"] = map[string]interface{}{}
@@ -288,30 +651,32 @@ func printService(provider terraformutils.ProviderGenerator, serviceName string,
 				bucket := terraformoutput.BucketState{
 					Name: options.Bucket,
 				}
-				for k := range provider.GetResourceConnections()[serviceName] {
-					if _, exist := importedResource[k]; !exist {
+				for k := range directory.Connections {
+					connected, exist := directories[k]
+					if !exist {
 						continue
 					}
 					variables["data"]["terraform_remote_state"][k] = map[string]interface{}{
 						"backend": "gcs",
-						"config":  bucket.BucketGetTfData(strings.ReplaceAll(path, serviceName, k)),
+						"config":  bucket.BucketGetTfData(connected.Path),
 					}
 				}
 			} else {
-				for k := range provider.GetResourceConnections()[serviceName] {
-					if _, exist := importedResource[k]; !exist {
+				for k := range directory.Connections {
+					connected, exist := directories[k]
+					if !exist {
 						continue
 					}
 					variables["data"]["terraform_remote_state"][k] = map[string]interface{}{
 						"backend": "local",
 						"config": map[string]interface{}{
-							"path": strings.Repeat("../", strings.Count(path, "/")) + strings.ReplaceAll(path, serviceName, k) + "terraform.tfstate",
+							"path": strings.Repeat("../", strings.Count(path, "/")) + connected.Path + "terraform.tfstate",
 						},
 					}
 				}
 			}
 			// create variables file
-			if len(provider.GetResourceConnections()[serviceName]) > 0 && options.Connect && len(variables["data"]["terraform_remote_state"]) > 0 {
+			if len(variables["data"]["terraform_remote_state"]) > 0 {
 				variablesFile, err := terraformutils.Print(variables, map[string]struct{}{"config": {}}, options.Output)
 				if err != nil {
 					return err
@@ -353,12 +718,97 @@ func printService(provider terraformutils.ProviderGenerator, serviceName string,
 	return nil
 }
 
-func Path(pathPattern, providerName, serviceName, output string) string {
-	return strings.NewReplacer(
-		"{provider}", providerName,
-		"{service}", serviceName,
-		"{output}", output,
-	).Replace(pathPattern)
+// printTfState writes the legacy state format, unless the plugin comes from another registry than
+// registry.terraform.io, which only the provider addresses of the version 4 format can name
+func printTfState(provider terraformutils.ProviderGenerator, resources []terraformutils.Resource, lineage string) ([]byte, error) {
+	providerSources := stateProviderSources(provider)
+	if providerSources == nil {
+		return terraformutils.PrintTfState(resources, lineage)
+	}
+	return terraformutils.PrintTfStateV4(resources, providerSources, lineage)
+}
+
+// stateLineage is the lineage of the state in path, derived from the path below the output directory
+// with --deterministic, empty for a new one
+func stateLineage(options ImportOptions, path string) string {
+	if !options.Deterministic {
+		return ""
+	}
+	if rel, err := filepath.Rel(options.PathOutput, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	return terraformutils.StableLineage(filepath.ToSlash(filepath.Clean(path)))
+}
+
+// stateProviderSources are the provider addresses of version 4 states, nil for the legacy format
+func stateProviderSources(provider terraformutils.ProviderGenerator) map[string]string {
+	address, err := providerwrapper.GetProviderAddress(provider.GetName())
+	if err != nil || address.Hostname == "" || address.Hostname == providerwrapper.DefaultRegistryHost {
+		return nil
+	}
+	return map[string]string{provider.GetName(): address.FullSource()}
+}
+
+func printTerragruntRoot(provider terraformutils.ProviderGenerator, options ImportOptions) error {
+	rootPath := PathWithAttributes(rootPathPattern(options.PathPattern), provider.GetName(), "", options.PathOutput, providerPathAttributes(provider, options.PathPattern))
+	providerFile, err := terraformutils.Print(terraformoutput.ProviderFileData(provider), map[string]struct{}{}, "hcl")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rootPath, os.ModePerm); err != nil {
+		return err
+	}
+	config := terraformoutput.TerragruntConfig{ProviderFile: providerFile}
+	terraformoutput.PrintFile(rootPath+"/"+terraformoutput.TerragruntFileName, config.Bytes())
+	return nil
+}
+
+func printTerragruntService(provider terraformutils.ProviderGenerator, directory *outputDirectory, options ImportOptions, directories map[string]*outputDirectory) error {
+	path := directory.Path
+	config := terraformoutput.TerragruntConfig{
+		IncludeRoot: directory.Name != "",
+		Backend:     "local",
+	}
+	if options.State == "bucket" {
+		bucket := terraformoutput.BucketState{
+			Name: options.Bucket,
+		}
+		config.Backend = "gcs"
+		config.BackendConfig = map[string]string{
+			"bucket": strings.ReplaceAll(options.Bucket, "gs://", ""),
+			"prefix": bucket.BucketPrefix(path),
+		}
+	}
+	if directory.Name == "" {
+		providerFile, err := terraformutils.Print(terraformoutput.ProviderFileData(provider), map[string]struct{}{}, "hcl")
+		if err != nil {
+			return err
+		}
+		config.ProviderFile = providerFile
+	} else if options.Connect {
+		dependencies := map[string][]terraformutils.Resource{}
+		for k := range directory.Connections {
+			if connected, exist := directories[k]; exist && k != directory.Name {
+				dependencies[k] = connected.Resources
+			}
+		}
+		config.Dependencies = terraformoutput.TerragruntDependencies(directory.Resources, dependencies, func(k string) string {
+			relativePath, err := filepath.Rel(path, directories[k].Path)
+			if err != nil {
+				return directories[k].Path
+			}
+			return relativePath
+		})
+	}
+	if len(config.Dependencies) > 0 {
+		variablesFile, err := terraformutils.Print(config.Variables(), map[string]struct{}{}, options.Output)
+		if err != nil {
+			return err
+		}
+		terraformoutput.PrintFile(path+"/variables."+terraformoutput.GetFileExtension(options.Output), variablesFile)
+	}
+	terraformoutput.PrintFile(path+"/"+terraformoutput.TerragruntFileName, config.Bytes())
+	return nil
 }
 
 func listCmd(provider terraformutils.ProviderGenerator) *cobra.Command {
@@ -401,4 +851,22 @@ func baseProviderFlags(flag *pflag.FlagSet, options *ImportOptions, sampleRes, s
 	flag.StringVarP(&options.Output, "output", "O", "hcl", "output format hcl or json")
 	flag.IntVarP(&options.RetryCount, "retry-number", "n", 5, "number of retries to perform when refresh fails")
 	flag.IntVarP(&options.RetrySleepMs, "retry-sleep-ms", "m", 300, "time in ms to sleep between retries")
+	flag.BoolVarP(&options.Terragrunt, "terragrunt", "", false, "generate terragrunt.hcl files instead of backend and remote state files")
+	flag.StringVarP(&options.PreviousState, "previous-state", "", "", "existing output directory or state file to match resource addresses with")
+	flag.BoolVarP(&options.KeepNames, "keep-names", "", false, "keep resource names of --previous-state instead of generating moved blocks")
+	flag.BoolVarP(&options.Validate, "validate-resources", "", false, "validate generated resources with the provider and drop computed attributes")
+	flag.BoolVarP(&options.Verify, "verify", "", false, "plan generated resources and ignore changes of attributes which still differ")
+	flag.StringVarP(&options.Documents, "documents", "", terraformoutput.DocumentsString, "write JSON and YAML attributes as string, jsonencode or file")
+	flag.StringVarP(&options.Graph, "graph", "", "", "write the dependency graph of imported resources to a file, e.g. graph.dot")
+	flag.StringVarP(&options.GraphFormat, "graph-format", "", "dot", "format of --graph: dot or json")
+	flag.StringSliceVarP(&options.AsDataSource, "as-data-source", "", []string{}, "services or resource types to write as data sources, e.g. vpc,aws_kms_key")
+	flag.StringVarP(&options.GitRepo, "git-repo", "", "", "local git working tree to write into, --path-output is relative to it")
+	flag.StringVarP(&options.GitBranch, "git-branch", "", "", "branch of --git-repo to check out, created when it doesn't exist")
+	flag.BoolVarP(&options.GitCommit, "git-commit", "", false, "commit the generated files to --git-repo with a summary of changed resources")
+	flag.BoolVarP(&options.IncludeManaged, "include-managed", "", false, "import resources owned by other controllers, like CloudFormation stacks, EKS or default VPCs")
+	flag.StringSliceVarP(&options.ManagedByTags, "managed-by-tags", "", []string{}, "tags or labels of resources owned by other controllers, key or key=regexp, e.g. crossplane.io/claim-name")
+	flag.StringSliceVarP(&options.ExcludeManaged, "exclude-managed", "", []string{}, "state files or directories of resources not to import, e.g. ../network,other.tfstate")
+	flag.BoolVarP(&options.Deterministic, "deterministic", "", false, "derive the lineage of states from their directory, so unchanged imports write the same files")
+	flag.IntVarP(&options.BatchSize, "batch-size", "", 0, "refresh and write resources of a type in batches of this size, memory doesn't grow with the number of resources")
+	flag.StringVarP(&options.RegistryHost, "registry-host", "", "", "registry of provider source and state addresses, e.g. registry.opentofu.org, by default the one the plugin is installed from")
 }
@@ -0,0 +1,102 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImportInParallelLimit(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning, done := 0, 0, 0
+	var imports []func() error
+	for i := 0; i < 20; i++ {
+		imports = append(imports, func() error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			done++
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := importInParallel(3, imports); err != nil {
+		t.Fatal(err)
+	}
+	if done != 20 {
+		t.Errorf("%d of 20 imports ran", done)
+	}
+	if maxRunning > 3 {
+		t.Errorf("%d imports ran at once, the limit is 3", maxRunning)
+	}
+}
+
+func TestImportInParallelStopsOnFirstError(t *testing.T) {
+	var mu sync.Mutex
+	var started []int
+	failure := errors.New("first failure")
+	var imports []func() error
+	for i := 0; i < 5; i++ {
+		i := i
+		imports = append(imports, func() error {
+			mu.Lock()
+			started = append(started, i)
+			mu.Unlock()
+			switch i {
+			case 1:
+				return failure
+			case 2:
+				return errors.New("second failure")
+			}
+			return nil
+		})
+	}
+	if err := importInParallel(1, imports); err != failure {
+		t.Errorf("expected the first error, got %v", err)
+	}
+	if len(started) != 2 {
+		t.Errorf("imports %v started, none should start after the first error", started)
+	}
+}
+
+func TestImportInParallelReturnsFirstError(t *testing.T) {
+	failure := errors.New("first failure")
+	release := make(chan struct{})
+	imports := []func() error{
+		func() error {
+			return failure
+		},
+		func() error {
+			<-release
+			return errors.New("later failure")
+		},
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	if err := importInParallel(2, imports); err != failure {
+		t.Errorf("expected the first error, got %v", err)
+	}
+}
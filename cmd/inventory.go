@@ -0,0 +1,150 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformerstring"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
+	"github.com/spf13/cobra"
+)
+
+// InventoryOptions collects the resources of all regions and projects of an inventory command
+type InventoryOptions struct {
+	Format     string
+	Refresh    bool
+	Attributes []string
+	File       string
+
+	mu      sync.Mutex
+	records []terraformoutput.InventoryRecord
+}
+
+func newInventoryCmd() *cobra.Command {
+	inventory := &InventoryOptions{}
+	options := ImportOptions{
+		Inventory: inventory,
+	}
+	cmd := &cobra.Command{
+		Use:           "inventory",
+		Short:         "List current resources without generating Terraform configuration",
+		Long:          "List current resources without generating Terraform configuration",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !terraformerstring.ContainsString(terraformoutput.InventoryFormats, inventory.Format) {
+				return fmt.Errorf("unknown inventory format %s, use csv, json or sqlite", inventory.Format)
+			}
+			if inventory.Format == "sqlite" && (inventory.File == "" || inventory.File == "-") {
+				return fmt.Errorf("sqlite inventory needs a database file, set --file")
+			}
+			return nil
+		},
+		// runs after the provider command imported all its regions and projects
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return inventory.write()
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&inventory.Format, "format", "", "csv", "csv, json or sqlite")
+	cmd.PersistentFlags().BoolVarP(&inventory.Refresh, "refresh", "", false, "read the resources with the provider plugin, it fills attributes and tags which discovery doesn't know")
+	cmd.PersistentFlags().StringSliceVarP(&inventory.Attributes, "attributes", "", []string{}, "attributes added to every resource, e.g. arn,vpc_id")
+	cmd.PersistentFlags().StringVarP(&inventory.File, "file", "", "-", "file to write, - for stdout")
+
+	for _, subcommand := range providerImporterSubcommands() {
+		providerCommand := subcommand(options)
+		_ = providerCommand.MarkPersistentFlagRequired("resources")
+		cmd.AddCommand(providerCommand)
+	}
+	return cmd
+}
+
+// Inventory discovers resources like Import, without provider plugin unless they are refreshed,
+// and adds them to the inventory instead of writing Terraform files
+func Inventory(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) error {
+	var providerWrapper *providerwrapper.ProviderWrapper
+	var err error
+	if options.Inventory.Refresh {
+		providerWrapper, options, err = initOptionsAndWrapper(provider, options, args)
+		if err != nil {
+			return err
+		}
+		defer providerWrapper.Kill()
+	} else {
+		options, err = initOptions(provider, options, args)
+		if err != nil {
+			return err
+		}
+	}
+	providerMapping := terraformutils.NewProvidersMapping(provider)
+	err = initAllServicesResources(providerMapping, options, args, providerWrapper, nil)
+	if err != nil {
+		return err
+	}
+	if providerWrapper != nil {
+		err = terraformutils.RefreshResourcesByProvider(providerMapping, providerWrapper)
+		if err != nil {
+			return err
+		}
+		if !options.IncludeManaged {
+			providerMapping.ExcludeManagedBy(options.managedByRules, nil)
+		}
+	}
+
+	pathAttributes := provider.GetPathAttributes()
+	var records []terraformoutput.InventoryRecord
+	for service, resources := range providerMapping.GetResourcesByService() {
+		for _, r := range resources {
+			records = append(records, terraformoutput.NewInventoryRecord(r, service, pathAttributes, options.Inventory.Attributes))
+		}
+	}
+	log.Printf("%s found %d resources\n", provider.GetName(), len(records))
+	options.Inventory.add(records)
+	return nil
+}
+
+func (i *InventoryOptions) add(records []terraformoutput.InventoryRecord) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.records = append(i.records, records...)
+}
+
+func (i *InventoryOptions) write() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	terraformoutput.SortInventory(i.records)
+	if i.Format == "sqlite" {
+		return terraformoutput.WriteInventorySQLite(i.File, i.records)
+	}
+	var w io.Writer = os.Stdout
+	if i.File != "-" {
+		f, err := os.Create(i.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if i.Format == "json" {
+		return terraformoutput.WriteInventoryJSON(w, i.records)
+	}
+	return terraformoutput.WriteInventoryCSV(w, i.records, i.Attributes)
+}
@@ -0,0 +1,251 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
+)
+
+const untaggedPathValue = "untagged"
+
+var providerPlaceholders = []string{"{region}", "{account}", "{project}"}
+
+var resourcePlaceholderRe = regexp.MustCompile(`{(resource_type|tag:[^}]+)}`)
+
+var unsafePathChars = regexp.MustCompile(`[^0-9A-Za-z_\-]`)
+
+// outputDirectory holds the resources written into one directory of the path pattern.
+type outputDirectory struct {
+	Name        string // name of terraform_remote_state pointing to the directory, empty for a single directory
+	ServiceName string
+	Path        string
+	Resources   []terraformutils.Resource
+	Connections map[string][]string // connection pairs by name of connected directory
+	Moved       []terraformoutput.Moved
+}
+
+func Path(pathPattern, providerName, serviceName, output string) string {
+	return PathWithAttributes(pathPattern, providerName, serviceName, output, nil)
+}
+
+// PathWithAttributes resolves the path pattern with additional placeholders,
+// e.g. {region} or {tag:team}
+func PathWithAttributes(pathPattern, providerName, serviceName, output string, attributes map[string]string) string {
+	replacements := []string{
+		"{provider}", providerName,
+		"{service}", serviceName,
+		"{output}", output,
+	}
+	for k, v := range attributes {
+		replacements = append(replacements, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(replacements...).Replace(pathPattern)
+}
+
+// terraformerPath is the directory for files about the import itself, like plan.json
+func terraformerPath(provider terraformutils.ProviderGenerator, options ImportOptions) string {
+	pathPattern := resourcePlaceholderRe.ReplaceAllString(options.PathPattern, "")
+	attributes := providerPathAttributes(provider, pathPattern)
+	return PathWithAttributes(pathPattern, provider.GetName(), "terraformer", options.PathOutput, attributes)
+}
+
+func hasResourcePlaceholders(pathPattern string) bool {
+	return resourcePlaceholderRe.MatchString(pathPattern)
+}
+
+// rootPathPattern is the part of the path pattern shared by all directories
+func rootPathPattern(pathPattern string) string {
+	end := len(pathPattern)
+	if i := strings.Index(pathPattern, "{service}"); i >= 0 {
+		end = i
+	}
+	if loc := resourcePlaceholderRe.FindStringIndex(pathPattern); loc != nil && loc[0] < end {
+		end = loc[0]
+	}
+	return pathPattern[:end]
+}
+
+func providerPathAttributes(provider terraformutils.ProviderGenerator, pathPattern string) map[string]string {
+	var names []string
+	for _, placeholder := range providerPlaceholders {
+		if strings.Contains(pathPattern, placeholder) {
+			names = append(names, strings.Trim(placeholder, "{}"))
+		}
+	}
+	if len(names) == 0 {
+		return map[string]string{}
+	}
+	if p, ok := provider.(terraformutils.PathAttributesProvider); ok {
+		return p.GetPathAttributesFor(names)
+	}
+	return provider.GetPathAttributes()
+}
+
+// validatePathPattern fails for provider placeholders the provider can't resolve, they would be
+// left in directory names as they are
+func validatePathPattern(provider terraformutils.ProviderGenerator, pathPattern string) error {
+	attributes := providerPathAttributes(provider, pathPattern)
+	for _, placeholder := range providerPlaceholders {
+		if strings.Contains(pathPattern, placeholder) && attributes[strings.Trim(placeholder, "{}")] == "" {
+			return fmt.Errorf("%s can't resolve %s of path pattern %s", provider.GetName(), placeholder, pathPattern)
+		}
+	}
+	return nil
+}
+
+// resourcePathAttributes returns values of per resource placeholders in order of appearance in the path pattern
+func resourcePathAttributes(pathPattern string, resource terraformutils.Resource) ([]string, map[string]string) {
+	var values []string
+	attributes := map[string]string{}
+	for _, match := range resourcePlaceholderRe.FindAllStringSubmatch(pathPattern, -1) {
+		value := untaggedPathValue
+		if match[1] == "resource_type" {
+			value = resource.InstanceInfo.Type
+		} else {
+			key := strings.TrimPrefix(match[1], "tag:")
+			for _, prefix := range []string{"tags.", "labels."} {
+				if tag, exist := resource.InstanceState.Attributes[prefix+key]; exist && tag != "" {
+					value = tag
+					break
+				}
+			}
+		}
+		value = unsafePathChars.ReplaceAllString(value, "_")
+		attributes[match[1]] = value
+		values = append(values, value)
+	}
+	return values, attributes
+}
+
+func serviceDirectories(provider terraformutils.ProviderGenerator, options ImportOptions, importedResource map[string][]terraformutils.Resource) []*outputDirectory {
+	attributes := providerPathAttributes(provider, options.PathPattern)
+	var services []string
+	for service := range importedResource {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	if !strings.Contains(options.PathPattern, "{service}") {
+		var compactedResources []terraformutils.Resource
+		for _, service := range services {
+			compactedResources = append(compactedResources, importedResource[service]...)
+		}
+		return []*outputDirectory{{
+			Path:      PathWithAttributes(options.PathPattern, provider.GetName(), "", options.PathOutput, attributes),
+			Resources: compactedResources,
+		}}
+	}
+	var directories []*outputDirectory
+	for _, serviceName := range services {
+		resources := importedResource[serviceName]
+		directories = append(directories, &outputDirectory{
+			Name:        serviceName,
+			ServiceName: serviceName,
+			Path:        PathWithAttributes(options.PathPattern, provider.GetName(), serviceName, options.PathOutput, attributes),
+			Resources:   resources,
+			Connections: provider.GetResourceConnections()[serviceName],
+		})
+	}
+	return directories
+}
+
+// resourceDirectories splits services into directories by per resource placeholders
+// and wires connections between directories holding connected services
+func resourceDirectories(provider terraformutils.ProviderGenerator, options ImportOptions, importedResource map[string][]terraformutils.Resource) []*outputDirectory {
+	attributes := providerPathAttributes(provider, options.PathPattern)
+	isServicePath := strings.Contains(options.PathPattern, "{service}")
+	var services []string
+	for service := range importedResource {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var directories []*outputDirectory
+	// directories are keyed by service and values, joined names of different ones may be the same
+	directoriesByKey := map[string]*outputDirectory{}
+	names := map[string]bool{}
+	servicesByDirectory := map[string]map[string]bool{}
+	for _, service := range services {
+		serviceName := ""
+		if isServicePath {
+			serviceName = service
+		}
+		for _, resource := range importedResource[service] {
+			values, resourceAttributes := resourcePathAttributes(options.PathPattern, resource)
+			for k, v := range attributes {
+				resourceAttributes[k] = v
+			}
+			key := strings.Join(append([]string{serviceName}, values...), "/")
+			directory, exist := directoriesByKey[key]
+			if !exist {
+				directory = &outputDirectory{
+					Name:        uniqueDirectoryName(strings.TrimPrefix(strings.Join(append([]string{serviceName}, values...), "_"), "_"), names),
+					ServiceName: serviceName,
+					Path:        PathWithAttributes(options.PathPattern, provider.GetName(), serviceName, options.PathOutput, resourceAttributes),
+					Connections: map[string][]string{},
+				}
+				directoriesByKey[key] = directory
+				servicesByDirectory[directory.Name] = map[string]bool{}
+				directories = append(directories, directory)
+			}
+			directory.Resources = append(directory.Resources, resource)
+			servicesByDirectory[directory.Name][service] = true
+		}
+	}
+
+	connections := provider.GetResourceConnections()
+	for _, directory := range directories {
+		for _, service := range sortedKeys(servicesByDirectory[directory.Name]) {
+			var connectedServices []string
+			for connectedService := range connections[service] {
+				connectedServices = append(connectedServices, connectedService)
+			}
+			sort.Strings(connectedServices)
+			for _, connectedService := range connectedServices {
+				for _, connected := range directories {
+					if servicesByDirectory[connected.Name][connectedService] {
+						directory.Connections[connected.Name] = append(directory.Connections[connected.Name], connections[service][connectedService]...)
+					}
+				}
+			}
+		}
+	}
+	return directories
+}
+
+// uniqueDirectoryName numbers names which are already taken, e.g. service a_b with tag c and
+// service a with tag b_c are both a_b_c
+func uniqueDirectoryName(name string, names map[string]bool) string {
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	names[unique] = true
+	return unique
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+type testPathProvider struct {
+	terraformutils.Provider
+	attributes  map[string]string
+	connections map[string]map[string][]string
+}
+
+func (p *testPathProvider) InitService(serviceName string, verbose bool) error { return nil }
+func (p *testPathProvider) GetName() string                                    { return "aws" }
+func (p *testPathProvider) GetProviderData(arg ...string) map[string]interface{} {
+	return map[string]interface{}{}
+}
+func (p *testPathProvider) GetPathAttributes() map[string]string { return p.attributes }
+func (p *testPathProvider) GetResourceConnections() map[string]map[string][]string {
+	return p.connections
+}
+
+func testPathResource(id, resourceType string, attributes map[string]string) terraformutils.Resource {
+	r := terraformutils.NewSimpleResource(id, id, resourceType, "aws", []string{})
+	for k, v := range attributes {
+		r.InstanceState.Attributes[k] = v
+	}
+	return r
+}
+
+func TestRootPathPattern(t *testing.T) {
+	cases := map[string]string{
+		"{output}/{provider}/{service}/":                  "{output}/{provider}/",
+		"{output}/{provider}/":                            "{output}/{provider}/",
+		"{output}/{provider}/{tag:team}/{service}/":       "{output}/{provider}/",
+		"{output}/{provider}/{service}/{resource_type}/":  "{output}/{provider}/",
+		"{output}/{region}/{resource_type}/{tag:env}/":    "{output}/{region}/",
+		"{output}/{provider}/{region}/{service}/{tag:a}/": "{output}/{provider}/{region}/",
+	}
+	for pattern, expected := range cases {
+		if root := rootPathPattern(pattern); root != expected {
+			t.Errorf("root of %s: expected %s, got %s", pattern, expected, root)
+		}
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	provider := &testPathProvider{attributes: map[string]string{"region": "eu-west-1"}}
+	cases := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"{output}/{provider}/{service}/", true},
+		{"{output}/{region}/{service}/", true},
+		{"{output}/{account}/{service}/", false},
+		{"{output}/{region}/{project}/{service}/", false},
+		{"{output}/{tag:team}/{resource_type}/", true},
+	}
+	for _, c := range cases {
+		if err := validatePathPattern(provider, c.pattern); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid %v, got %v", c.pattern, c.valid, err)
+		}
+	}
+}
+
+func TestResourcePathAttributes(t *testing.T) {
+	cases := []struct {
+		name       string
+		pattern    string
+		attributes map[string]string
+		values     []string
+	}{
+		{
+			name:       "placeholders in order of the pattern",
+			pattern:    "{output}/{tag:team}/{resource_type}/{tag:env}/",
+			attributes: map[string]string{"tags.team": "payments", "tags.env": "prod"},
+			values:     []string{"payments", "aws_vpc", "prod"},
+		},
+		{
+			name:       "untagged",
+			pattern:    "{output}/{tag:team}/",
+			attributes: map[string]string{"tags.env": "prod"},
+			values:     []string{untaggedPathValue},
+		},
+		{
+			name:       "empty tag is untagged",
+			pattern:    "{output}/{tag:team}/",
+			attributes: map[string]string{"tags.team": ""},
+			values:     []string{untaggedPathValue},
+		},
+		{
+			name:       "labels",
+			pattern:    "{output}/{tag:team}/",
+			attributes: map[string]string{"labels.team": "data"},
+			values:     []string{"data"},
+		},
+		{
+			name:       "tags before labels",
+			pattern:    "{output}/{tag:team}/",
+			attributes: map[string]string{"tags.team": "payments", "labels.team": "data"},
+			values:     []string{"payments"},
+		},
+		{
+			name:       "sanitized",
+			pattern:    "{output}/{tag:team}/",
+			attributes: map[string]string{"tags.team": "web/api team.v2"},
+			values:     []string{"web_api_team_v2"},
+		},
+	}
+	for _, c := range cases {
+		values, attributes := resourcePathAttributes(c.pattern, testPathResource("vpc-1", "aws_vpc", c.attributes))
+		if !reflect.DeepEqual(values, c.values) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.values, values)
+		}
+		if len(attributes) != len(c.values) {
+			t.Errorf("%s: expected an attribute per placeholder, got %v", c.name, attributes)
+		}
+	}
+}
+
+func TestResourceDirectories(t *testing.T) {
+	provider := &testPathProvider{
+		attributes: map[string]string{"region": "eu-west-1"},
+		connections: map[string]map[string][]string{
+			"subnet": {"vpc": []string{"vpc_id", "id"}},
+			"sg":     {"vpc": []string{"vpc_id", "id"}, "subnet": []string{"subnet_id", "id"}},
+		},
+	}
+	options := ImportOptions{PathPattern: "{output}/{region}/{service}/{tag:team}/", PathOutput: "generated"}
+	imported := map[string][]terraformutils.Resource{
+		"vpc": {
+			testPathResource("vpc-1", "aws_vpc", map[string]string{"tags.team": "payments"}),
+			testPathResource("vpc-2", "aws_vpc", nil),
+		},
+		"subnet": {testPathResource("subnet-1", "aws_subnet", map[string]string{"tags.team": "payments"})},
+		"sg":     {testPathResource("sg-1", "aws_security_group", map[string]string{"labels.team": "payments"})},
+	}
+	directories := resourceDirectories(provider, options, imported)
+
+	paths := map[string]string{}
+	resources := map[string]int{}
+	for _, directory := range directories {
+		paths[directory.Name] = directory.Path
+		resources[directory.Name] = len(directory.Resources)
+	}
+	expectedPaths := map[string]string{
+		"sg_payments":     "generated/eu-west-1/sg/payments/",
+		"subnet_payments": "generated/eu-west-1/subnet/payments/",
+		"vpc_payments":    "generated/eu-west-1/vpc/payments/",
+		"vpc_untagged":    "generated/eu-west-1/vpc/untagged/",
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("expected directories %v, got %v", expectedPaths, paths)
+	}
+	for name, count := range resources {
+		if count != 1 {
+			t.Errorf("expected a resource in %s, got %d", name, count)
+		}
+	}
+
+	connections := map[string]map[string][]string{}
+	for _, directory := range directories {
+		connections[directory.Name] = directory.Connections
+	}
+	expectedConnections := map[string]map[string][]string{
+		"sg_payments": {
+			"subnet_payments": {"subnet_id", "id"},
+			"vpc_payments":    {"vpc_id", "id"},
+			"vpc_untagged":    {"vpc_id", "id"},
+		},
+		"subnet_payments": {
+			"vpc_payments": {"vpc_id", "id"},
+			"vpc_untagged": {"vpc_id", "id"},
+		},
+		"vpc_payments": {},
+		"vpc_untagged": {},
+	}
+	if !reflect.DeepEqual(connections, expectedConnections) {
+		t.Errorf("expected connections %v, got %v", expectedConnections, connections)
+	}
+}
+
+func TestResourceDirectoriesCollidingNames(t *testing.T) {
+	provider := &testPathProvider{}
+	options := ImportOptions{PathPattern: "{output}/{service}/{tag:team}/", PathOutput: "generated"}
+	imported := map[string][]terraformutils.Resource{
+		"a_b": {testPathResource("r-1", "aws_vpc", map[string]string{"tags.team": "c"})},
+		"a":   {testPathResource("r-2", "aws_vpc", map[string]string{"tags.team": "b_c"})},
+	}
+	directories := resourceDirectories(provider, options, imported)
+	if len(directories) != 2 {
+		t.Fatalf("expected 2 directories, got %d", len(directories))
+	}
+	// services are sorted, a comes first and keeps the name
+	expected := [][2]string{{"a_b_c", "generated/a/b_c/"}, {"a_b_c_2", "generated/a_b/c/"}}
+	for i, directory := range directories {
+		if directory.Name != expected[i][0] || directory.Path != expected[i][1] {
+			t.Errorf("expected %s in %s, got %s in %s", expected[i][0], expected[i][1], directory.Name, directory.Path)
+		}
+		if len(directory.Resources) != 1 {
+			t.Errorf("expected a resource in %s, got %d", directory.Name, len(directory.Resources))
+		}
+	}
+}
@@ -16,9 +16,11 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
 	"github.com/spf13/cobra"
@@ -30,6 +32,8 @@ type ImportPlan struct {
 	Options          ImportOptions
 	Args             []string
 	ImportedResource map[string][]terraformutils.Resource
+	Report           *terraformutils.ImportReport `json:",omitempty"`
+	Aliases          []ProviderAlias              `json:",omitempty"`
 }
 
 func newPlanCmd() *cobra.Command {
@@ -45,12 +49,58 @@ func newPlanCmd() *cobra.Command {
 		//Version:       version.String(),
 	}
 
+	cmd.AddCommand(newCmdPlanDiff())
 	for _, subcommand := range providerImporterSubcommands() {
 		cmd.AddCommand(subcommand(options))
 	}
 	return cmd
 }
 
+func newCmdPlanDiff() *cobra.Command {
+	format := "text"
+	var ignore []string
+	cmd := &cobra.Command{
+		Use:   "diff old.json new.json",
+		Short: "Compare the resources of two planfiles",
+		Long:  "Compare the resources of two planfiles by type and ID, without access to the provider",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unknown diff format %s, use text or json", format)
+			}
+			var ignoreKeys []*regexp.Regexp
+			for _, pattern := range ignore {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid ignore pattern %s: %v", pattern, err)
+				}
+				ignoreKeys = append(ignoreKeys, re)
+			}
+			oldPlan, err := readPlanfile(args[0])
+			if err != nil {
+				return err
+			}
+			newPlan, err := readPlanfile(args[1])
+			if err != nil {
+				return err
+			}
+			if oldPlan.Provider != newPlan.Provider {
+				return fmt.Errorf("planfiles are of different providers: %s and %s", oldPlan.Provider, newPlan.Provider)
+			}
+			diff := terraformutils.DiffResources(oldPlan.ImportedResource, newPlan.ImportedResource, ignoreKeys)
+			if format == "json" {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(diff)
+			}
+			return diff.WriteText(cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "", format, "text or json")
+	cmd.Flags().StringSliceVarP(&ignore, "ignore", "", []string{}, "regular expressions of attributes not compared, e.g. ^tags_all\\.")
+	return cmd
+}
+
 func newCmdPlanImporter(options ImportOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "plan",
@@ -64,14 +114,20 @@ func newCmdPlanImporter(options ImportOptions) *cobra.Command {
 			}
 
 			var provider terraformutils.ProviderGenerator
-			if providerGen, ok := providerGenerators()[plan.Provider]; ok {
-				provider = providerGen()
-			} else {
+			providerGen, ok := providerGenerators()[plan.Provider]
+			if !ok {
 				return fmt.Errorf("unsupported provider: %s", plan.Provider)
 			}
-
-			if err = provider.Init(plan.Args); err != nil {
-				return err
+			if len(plan.Aliases) > 0 {
+				provider, err = newAliasedProvider(providerGen, plan.Aliases)
+				if err != nil {
+					return err
+				}
+			} else {
+				provider = providerGen()
+				if err = provider.Init(plan.Args); err != nil {
+					return err
+				}
 			}
 
 			for _, service := range plan.Options.Resources {
@@ -87,6 +143,19 @@ func newCmdPlanImporter(options ImportOptions) *cobra.Command {
 }
 
 func LoadPlanfile(path string) (*ImportPlan, error) {
+	plan, err := readPlanfile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Version != version {
+		return nil, fmt.Errorf("planfile version did not match. expected: %s, actual: %s", version, plan.Version)
+	}
+
+	return plan, nil
+}
+
+func readPlanfile(path string) (*ImportPlan, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -99,11 +168,6 @@ func LoadPlanfile(path string) (*ImportPlan, error) {
 	if err := dec.Decode(plan); err != nil {
 		return nil, err
 	}
-
-	if plan.Version != version {
-		return nil, fmt.Errorf("planfile version did not match. expected: %s, actual: %s", version, plan.Version)
-	}
-
 	return plan, nil
 }
 
@@ -127,3 +191,17 @@ func ExportPlanFile(plan *ImportPlan, path, filename string) error {
 	enc.SetIndent("", "\t")
 	return enc.Encode(plan)
 }
+
+func ExportReportFile(report *terraformutils.ImportReport, path, filename string) error {
+	reportPath := filepath.Join(path, filename)
+	log.Println("Saving import report to", reportPath)
+
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(reportPath, data, os.ModePerm)
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// ProviderAlias is a region, project or subscription imported with its own aliased provider block
+type ProviderAlias struct {
+	Alias string
+	// Args initialize the provider of the alias
+	Args []string
+	// Resources are the services imported with the alias, all of --resources when empty
+	Resources []string `json:",omitempty"`
+	// Config is added to the provider block of the alias
+	Config map[string]interface{} `json:",omitempty"`
+}
+
+var invalidAliasChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// aliasName turns a region, project or subscription into a provider alias, like eu-west-1 into eu_west_1
+func aliasName(name string) string {
+	alias := invalidAliasChars.ReplaceAllString(strings.ToLower(name), "_")
+	if alias == "" || (alias[0] >= '0' && alias[0] <= '9') {
+		alias = "_" + alias
+	}
+	return alias
+}
+
+// aliasedProvider writes one provider block per alias, everything else comes from the provider of the first alias
+type aliasedProvider struct {
+	terraformutils.ProviderGenerator
+	providers []terraformutils.ProviderGenerator
+	aliases   []ProviderAlias
+}
+
+func newAliasedProvider(newProvider func() terraformutils.ProviderGenerator, aliases []ProviderAlias) (*aliasedProvider, error) {
+	p := &aliasedProvider{aliases: aliases}
+	for _, alias := range aliases {
+		provider := newProvider()
+		if err := provider.Init(alias.Args); err != nil {
+			return nil, err
+		}
+		p.providers = append(p.providers, provider)
+	}
+	p.ProviderGenerator = p.providers[0]
+	return p, nil
+}
+
+func (p *aliasedProvider) GetProviderData(arg ...string) map[string]interface{} {
+	providerData := p.ProviderGenerator.GetProviderData(arg...)
+	var blocks []interface{}
+	seen := map[string]bool{}
+	for i, provider := range p.providers {
+		alias := p.aliases[i]
+		if seen[alias.Alias] {
+			continue
+		}
+		seen[alias.Alias] = true
+		config := map[string]interface{}{}
+		if data, ok := provider.GetProviderData(arg...)["provider"].(map[string]interface{}); ok {
+			if c, ok := data[p.GetName()].(map[string]interface{}); ok {
+				for k, v := range c {
+					config[k] = v
+				}
+			}
+		}
+		for k, v := range alias.Config {
+			config[k] = v
+		}
+		config["alias"] = alias.Alias
+		blocks = append(blocks, map[string]interface{}{p.GetName(): config})
+	}
+	// a list of provider blocks is printed as repeated provider "name" blocks
+	providerData["provider"] = blocks
+	return providerData
+}
+
+// importWithProviderAliases imports every alias on its own and writes them into the same directories,
+// resources get the provider of their alias and its name as suffix
+func importWithProviderAliases(newProvider func() terraformutils.ProviderGenerator, options ImportOptions, aliases []ProviderAlias) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	if options.Inventory != nil {
+		for _, alias := range aliases {
+			aliasOptions := options
+			if len(alias.Resources) > 0 {
+				aliasOptions.Resources = alias.Resources
+			}
+			if err := Inventory(newProvider(), aliasOptions, alias.Args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if strings.Contains(options.PathPattern, "{region}") {
+		return errors.New("--provider-aliases writes all regions into the same directories, remove {region} from --path-pattern")
+	}
+	plans := make([]*ImportPlan, len(aliases))
+	var imports []func() error
+	for i := range aliases {
+		i := i
+		imports = append(imports, func() error {
+			provider := newProvider()
+			aliasOptions := options
+			if len(aliases[i].Resources) > 0 {
+				aliasOptions.Resources = aliases[i].Resources
+			}
+			log.Println(provider.GetName() + " importing " + aliases[i].Alias)
+			plan, err := newImportPlan(provider, aliasOptions, aliases[i].Args)
+			plans[i] = plan
+			return err
+		})
+	}
+	if err := importInParallel(options.RegionParallelism, imports); err != nil {
+		return err
+	}
+	provider, err := newAliasedProvider(newProvider, aliases)
+	if err != nil {
+		return err
+	}
+	return importFromPlan(provider, mergeAliasedPlans(plans, aliases))
+}
+
+func mergeAliasedPlans(plans []*ImportPlan, aliases []ProviderAlias) *ImportPlan {
+	merged := &ImportPlan{
+		Provider:         plans[0].Provider,
+		Options:          plans[0].Options,
+		Args:             plans[0].Args,
+		ImportedResource: map[string][]terraformutils.Resource{},
+		Report:           terraformutils.NewImportReport(),
+		Aliases:          aliases,
+	}
+	var services []string
+	for i, plan := range plans {
+		suffix := "_" + aliases[i].Alias
+		for service, resources := range plan.ImportedResource {
+			if _, exist := merged.ImportedResource[service]; !exist {
+				services = append(services, service)
+			}
+			for _, r := range resources {
+				r.ResourceName += suffix
+				r.ProviderAlias = aliases[i].Alias
+				merged.ImportedResource[service] = append(merged.ImportedResource[service], r)
+			}
+		}
+		merged.Report.Merge(plan.Report, func(address string) string {
+			return address + suffix
+		})
+	}
+	merged.Options.Resources = services
+	return merged
+}
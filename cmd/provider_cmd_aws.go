@@ -14,7 +14,9 @@
 package cmd
 
 import (
+	"errors"
 	"log"
+	"strings"
 
 	awsterraformer "github.com/GoogleCloudPlatform/terraformer/providers/aws"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -27,6 +29,9 @@ func newCmdAwsImporter(options ImportOptions) *cobra.Command {
 		Short: "Import current state to Terraform configuration from AWS",
 		Long:  "Import current state to Terraform configuration from AWS",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.ProviderAliases {
+				return importAWSWithProviderAliases(options)
+			}
 			originalResources := options.Resources
 			originalRegions := options.Regions
 			originalPathPattern := options.PathPattern
@@ -54,12 +59,18 @@ func newCmdAwsImporter(options ImportOptions) *cobra.Command {
 					if len(globalResources) > 0 {
 						shouldSpecifyPathRegion = true // we should keep global resources away from regional
 					}
+					// plugins of all regions get the credentials, even when the first ones start at the same time
+					if e := awsterraformer.PrepareCredentials(originalRegions[0], options.Profile); e != nil {
+						return e
+					}
+					var imports []func() error
 					for _, region := range originalRegions {
-						e := importRegionResources(options, originalPathPattern, region, shouldSpecifyPathRegion)
-						if e != nil {
-							return e
-						}
+						region := region
+						imports = append(imports, func() error {
+							return importRegionResources(options, originalPathPattern, region, shouldSpecifyPathRegion)
+						})
 					}
+					return importInParallel(options.RegionParallelism, imports)
 				}
 				return nil
 			}
@@ -75,6 +86,8 @@ func newCmdAwsImporter(options ImportOptions) *cobra.Command {
 
 	cmd.PersistentFlags().StringVarP(&options.Profile, "profile", "", "default", "prod")
 	cmd.PersistentFlags().StringSliceVarP(&options.Regions, "regions", "", []string{}, "eu-west-1,eu-west-2,us-east-1")
+	cmd.PersistentFlags().IntVarP(&options.RegionParallelism, "region-parallelism", "", 1, "number of regions imported at once")
+	cmd.PersistentFlags().BoolVarP(&options.ProviderAliases, "provider-aliases", "", false, "import all regions into the same directories with an aliased provider per region")
 	return cmd
 }
 
@@ -93,6 +106,45 @@ func parseAndGroupResources(allResources []string) ([]string, []string, []string
 	return globalResources, eastOnlyResources, regionalResources
 }
 
+// importAWSWithProviderAliases imports global, east-only and regional resources with their own aliased provider
+func importAWSWithProviderAliases(options ImportOptions) error {
+	if len(options.Regions) == 0 {
+		return errors.New("--provider-aliases needs --regions")
+	}
+	globalResources, eastOnlyResources, regionalResources := parseAndGroupResources(options.Resources)
+	var aliases []ProviderAlias
+	if len(globalResources) > 0 {
+		aliases = append(aliases, ProviderAlias{
+			Alias:     "global",
+			Args:      []string{awsterraformer.GlobalRegion, options.Profile},
+			Resources: globalResources,
+		})
+	}
+	if len(eastOnlyResources) > 0 {
+		aliases = append(aliases, ProviderAlias{
+			Alias:     aliasName(awsterraformer.MainRegionPublicPartition),
+			Args:      []string{awsterraformer.MainRegionPublicPartition, options.Profile},
+			Resources: eastOnlyResources,
+		})
+	}
+	if len(regionalResources) > 0 {
+		for _, region := range options.Regions {
+			aliases = append(aliases, ProviderAlias{
+				Alias:     aliasName(region),
+				Args:      []string{region, options.Profile},
+				Resources: regionalResources,
+			})
+		}
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+	if err := awsterraformer.PrepareCredentials(options.Regions[0], options.Profile); err != nil {
+		return err
+	}
+	return importWithProviderAliases(newAWSProvider, options, aliases)
+}
+
 func importGlobalResources(options ImportOptions) error {
 	if len(options.Resources) > 0 {
 		return importRegionResources(options, options.PathPattern, awsterraformer.GlobalRegion, false)
@@ -111,7 +163,7 @@ func importRegionResources(options ImportOptions, originalPathPattern string, re
 	provider := newAWSProvider()
 	options.PathPattern = originalPathPattern
 	if region != awsterraformer.GlobalRegion && region != awsterraformer.NoRegion {
-		if shouldSpecifyPathRegion {
+		if shouldSpecifyPathRegion && !strings.Contains(options.PathPattern, "{region}") {
 			options.PathPattern += region + "/"
 		}
 		log.Println(provider.GetName() + " importing region " + region)
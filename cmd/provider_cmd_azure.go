@@ -14,6 +14,8 @@
 package cmd
 
 import (
+	"errors"
+
 	azure_terraforming "github.com/GoogleCloudPlatform/terraformer/providers/azure"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -21,11 +23,26 @@ import (
 )
 
 func newCmdAzureImporter(options ImportOptions) *cobra.Command {
+	var subscriptions []string
 	cmd := &cobra.Command{
 		Use:   "azure",
 		Short: "Import current state to Terraform configuration from Azure",
 		Long:  "Import current state to Terraform configuration from Azure",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(subscriptions) > 0 {
+				if !options.ProviderAliases {
+					return errors.New("--subscriptions needs --provider-aliases")
+				}
+				var aliases []ProviderAlias
+				for _, subscription := range subscriptions {
+					aliases = append(aliases, ProviderAlias{
+						Alias:  aliasName("sub_" + subscription),
+						Args:   []string{options.ResourceGroup, subscription},
+						Config: map[string]interface{}{"subscription_id": subscription},
+					})
+				}
+				return importWithProviderAliases(newAzureProvider, options, aliases)
+			}
 			provider := newAzureProvider()
 			err := Import(provider, options, []string{options.ResourceGroup})
 			if err != nil {
@@ -38,6 +55,9 @@ func newCmdAzureImporter(options ImportOptions) *cobra.Command {
 	cmd.AddCommand(listCmd(newAzureProvider()))
 	baseProviderFlags(cmd.PersistentFlags(), &options, "resource_group", "resource_group=name1:name2:name3")
 	cmd.PersistentFlags().StringVarP(&options.ResourceGroup, "resource-group", "R", "", "")
+	cmd.PersistentFlags().StringSliceVarP(&subscriptions, "subscriptions", "", []string{}, "subscription ids imported instead of ARM_SUBSCRIPTION_ID")
+	cmd.PersistentFlags().BoolVarP(&options.ProviderAliases, "provider-aliases", "", false, "import all subscriptions into the same directories with an aliased provider per subscription")
+	cmd.PersistentFlags().IntVarP(&options.RegionParallelism, "region-parallelism", "", 1, "number of subscriptions imported at once")
 	return cmd
 }
 
@@ -0,0 +1,49 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+
+	generic_terraforming "github.com/GoogleCloudPlatform/terraformer/providers/generic"
+
+	"github.com/spf13/cobra"
+)
+
+func newCmdGenericImporter(options ImportOptions) *cobra.Command {
+	var providerName, resourceType, ids string
+	var providerConfig []string
+	cmd := &cobra.Command{
+		Use:   "generic",
+		Short: "Import resources of any type of a provider plugin by ID",
+		Long:  "Import resources of any type of a provider plugin by ID, with one ID per line or type,id,name lines of a .csv file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if providerName == "" || ids == "" {
+				return errors.New("--provider-name and --ids are required")
+			}
+			if len(options.Resources) == 0 {
+				options.Resources = []string{"*"}
+			}
+			provider := &generic_terraforming.GenericProvider{}
+			return Import(provider, options, append([]string{providerName, ids, resourceType}, providerConfig...))
+		},
+	}
+	cmd.Flags().StringVarP(&providerName, "provider-name", "", "", "name of the provider plugin, e.g. aws")
+	cmd.Flags().StringVarP(&resourceType, "type", "", "", "resource type of IDs without type, e.g. aws_sqs_queue")
+	cmd.Flags().StringVarP(&ids, "ids", "", "", "file with one ID per line or a .csv file with type,id,name")
+	cmd.Flags().StringSliceVarP(&providerConfig, "provider-config", "", []string{}, "provider configuration, e.g. region=eu-west-1")
+	baseProviderFlags(cmd.PersistentFlags(), &options, "resource types without provider prefix, default all types of --ids", "")
+	return cmd
+}
@@ -29,31 +29,62 @@ func newCmdGoogleImporter(options ImportOptions) *cobra.Command {
 		Short: "Import current state to Terraform configuration from Google Cloud",
 		Long:  "Import current state to Terraform configuration from Google Cloud",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.ProviderAliases {
+				return importWithProviderAliases(newGoogleProvider, options, googleProviderAliases(options, providerType))
+			}
 			originalPathPattern := options.PathPattern
+			var imports []func() error
 			for _, project := range options.Projects {
 				for _, region := range options.Regions {
-					provider := newGoogleProvider()
-					options.PathPattern = originalPathPattern
-					options.PathPattern = strings.ReplaceAll(options.PathPattern, "{provider}/{service}", "{provider}/"+project+"/{service}/"+region)
-					log.Println(provider.GetName() + " importing project " + project + " region " + region)
-					err := Import(provider, options, []string{region, project, providerType})
-					if err != nil {
-						return err
-					}
+					project, region := project, region
+					imports = append(imports, func() error {
+						provider := newGoogleProvider()
+						options := options
+						options.PathPattern = originalPathPattern
+						if !strings.Contains(options.PathPattern, "{project}") && !strings.Contains(options.PathPattern, "{region}") {
+							options.PathPattern = strings.ReplaceAll(options.PathPattern, "{provider}/{service}", "{provider}/"+project+"/{service}/"+region)
+						}
+						log.Println(provider.GetName() + " importing project " + project + " region " + region)
+						return Import(provider, options, []string{region, project, providerType})
+					})
 				}
 			}
-			return nil
+			return importInParallel(options.RegionParallelism, imports)
 		},
 	}
 	cmd.AddCommand(listCmd(newGoogleProvider()))
 	baseProviderFlags(cmd.PersistentFlags(), &options, "firewalls,networks", "compute_firewall=id1:id2:id4")
 	cmd.PersistentFlags().StringSliceVarP(&options.Regions, "regions", "z", []string{"global"}, "europe-west1,")
 	cmd.PersistentFlags().StringSliceVarP(&options.Projects, "projects", "", []string{}, "")
+	cmd.PersistentFlags().IntVarP(&options.RegionParallelism, "region-parallelism", "", 1, "number of project and region combinations imported at once")
+	cmd.PersistentFlags().BoolVarP(&options.ProviderAliases, "provider-aliases", "", false, "import all projects and regions into the same directories with an aliased provider per combination")
 	cmd.PersistentFlags().StringVarP(&providerType, "provider-type", "", "", "beta")
 	_ = cmd.MarkPersistentFlagRequired("projects")
 	return cmd
 }
 
+// googleProviderAliases names the aliases by region, prefixed by the project when several projects are imported
+func googleProviderAliases(options ImportOptions, providerType string) []ProviderAlias {
+	var aliases []ProviderAlias
+	for _, project := range options.Projects {
+		for _, region := range options.Regions {
+			name := region
+			if len(options.Projects) > 1 {
+				name = project + "_" + region
+			}
+			alias := ProviderAlias{
+				Alias: aliasName(name),
+				Args:  []string{region, project, providerType},
+			}
+			if region != "global" {
+				alias.Config = map[string]interface{}{"region": region}
+			}
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
 func newGoogleProvider() terraformutils.ProviderGenerator {
 	return &gcp_terraforming.GCPProvider{}
 }
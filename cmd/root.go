@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
 	"github.com/spf13/cobra"
 )
 
@@ -27,11 +28,14 @@ func NewCmdRoot() *cobra.Command {
 	}
 	cmd.AddCommand(newImportCmd())
 	cmd.AddCommand(newPlanCmd())
+	cmd.AddCommand(newInventoryCmd())
+	cmd.AddCommand(newServeCmd())
 	cmd.AddCommand(versionCmd)
 	return cmd
 }
 
 func Execute() error {
+	defer providerwrapper.ClosePool()
 	cmd := NewCmdRoot()
 	return cmd.Execute()
 }
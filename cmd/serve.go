@@ -0,0 +1,275 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ServeTokenEnv is the default of --token, tokens in arguments are visible to other users of the host
+const ServeTokenEnv = "TERRAFORMER_SERVE_TOKEN"
+
+// jobArgFlags are the flags of provider commands a job may pass as Args, credentials and the
+// scope of the import. Flags taking files of the host, like --credentials or --ids, are left out.
+var jobArgFlags = map[string]bool{
+	"account-id":         true,
+	"address":            true,
+	"api-key":            true,
+	"api-url":            true,
+	"apikey":             true,
+	"app-key":            true,
+	"base-url":           true,
+	"cis":                true,
+	"email":              true,
+	"group":              true,
+	"owner":              true,
+	"provider-aliases":   true,
+	"provider-type":      true,
+	"region":             true,
+	"region-parallelism": true,
+	"server":             true,
+	"subscriptions":      true,
+	"targets":            true,
+	"token":              true,
+	"validate":           true,
+	"vsys":               true,
+}
+
+func newServeCmd() *cobra.Command {
+	listen := "127.0.0.1:8080"
+	concurrency := 2
+	jobsDir := "terraformer-jobs"
+	token := os.Getenv(ServeTokenEnv)
+	retention := 24 * time.Hour
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run imports as jobs of an HTTP API",
+		Long:  "Run imports as jobs of an HTTP API, every job runs terraformer plan and import plan into its own directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			dir, err := filepath.Abs(jobsDir)
+			if err != nil {
+				return err
+			}
+			s := server.New(context.Background(), dir, concurrency, jobRunner(executable))
+			s.Token = token
+			s.Retention = retention
+			s.Validate = func(request server.JobRequest) error {
+				// paths are checked the same way in every job directory
+				_, err := jobArgs(request, filepath.Join(dir, "job"))
+				return err
+			}
+			if token == "" {
+				log.Printf("WARNING: the API runs imports with the credentials of this host for everyone who can reach %s, set --token or %s", listen, ServeTokenEnv)
+			}
+			log.Printf("serving on %s, jobs are written to %s", listen, dir)
+			return http.ListenAndServe(listen, s.Handler())
+		},
+	}
+	cmd.Flags().StringVarP(&listen, "listen", "", listen, "address of the HTTP API")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "", concurrency, "number of jobs running at once, others are queued")
+	cmd.Flags().StringVarP(&jobsDir, "jobs-dir", "", jobsDir, "directory of the files generated by jobs")
+	cmd.Flags().StringVarP(&token, "token", "", token, "bearer token required by the API, by default "+ServeTokenEnv)
+	cmd.Flags().DurationVarP(&retention, "retention", "", retention, "how long finished jobs and their files are kept, 0 keeps them until they are deleted")
+	return cmd
+}
+
+// jobRunner runs the plan of the job and imports every planfile, in processes of their own
+// since imports change environment variables and log globally
+func jobRunner(executable string) server.Runner {
+	return func(ctx context.Context, job *server.Job, output io.Writer) error {
+		args, err := jobArgs(job.Request, job.Dir)
+		if err != nil {
+			return err
+		}
+		if err := runTerraformer(ctx, executable, append([]string{"plan", job.Request.Provider}, args...), output); err != nil {
+			return err
+		}
+		var plans []string
+		err = filepath.Walk(job.Dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && info.Name() == "plan.json" {
+				plans = append(plans, path)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		sort.Strings(plans)
+		for _, plan := range plans {
+			if err := runTerraformer(ctx, executable, []string{"import", "plan", plan}, output); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func runTerraformer(ctx context.Context, executable string, args []string, output io.Writer) error {
+	fmt.Fprintln(output, "terraformer "+strings.Join(args, " "))
+	c := exec.CommandContext(ctx, executable, args...)
+	c.Env = append(os.Environ(), terraformutils.ProgressEnv+"=1")
+	c.Stdout = output
+	c.Stderr = output
+	return c.Run()
+}
+
+// jobArgs turns the options of the request into flags of the provider command, followed by the
+// provider flags of the request and --path-output in dir. Files of options have to be in dir.
+func jobArgs(request server.JobRequest, dir string) ([]string, error) {
+	known := false
+	for _, subcommand := range providerImporterSubcommands() {
+		if subcommand(ImportOptions{}).Name() == request.Provider {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unsupported provider: %s", request.Provider)
+	}
+
+	options := ImportOptions{}
+	flags := pflag.NewFlagSet(request.Provider, pflag.ContinueOnError)
+	baseProviderFlags(flags, &options, "", "")
+	if len(request.Options) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(request.Options))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&options); err != nil {
+			return nil, fmt.Errorf("invalid options: %v", err)
+		}
+	}
+	if err := jobPaths(&options, dir); err != nil {
+		return nil, err
+	}
+	var args []string
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "path-output" {
+			return
+		}
+		value := flag.Value.String()
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			if value, err = joinFlagValues(slice.GetSlice()); err != nil {
+				return
+			}
+			if len(slice.GetSlice()) == 0 {
+				return
+			}
+		} else if value == flag.DefValue {
+			return
+		}
+		args = append(args, "--"+flag.Name+"="+value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// flags of some provider commands only, flags without options like --provider-aliases are Args
+	if options.Profile != "" {
+		args = append(args, "--profile="+options.Profile)
+	}
+	if options.ResourceGroup != "" {
+		args = append(args, "--resource-group="+options.ResourceGroup)
+	}
+	for name, values := range map[string][]string{"regions": options.Regions, "projects": options.Projects} {
+		if len(values) > 0 {
+			value, err := joinFlagValues(values)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--"+name+"="+value)
+		}
+	}
+
+	for _, arg := range request.Args {
+		name := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]
+		if !strings.HasPrefix(arg, "--") || !jobArgFlags[name] {
+			return nil, fmt.Errorf("arg %s isn't allowed, pass --name=value of a provider flag or use options", arg)
+		}
+	}
+	args = append(args, request.Args...)
+	args = append(args, "--path-output="+filepath.Join(dir, DefaultPathOutput))
+	return args, nil
+}
+
+// jobPaths makes the files of options absolute paths in dir, relative paths are relative to dir.
+// Paths outside of dir are rejected, jobs can't read or write other files of the host.
+func jobPaths(options *ImportOptions, dir string) error {
+	var err error
+	inDir := func(path string) string {
+		if err != nil || path == "" {
+			return path
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path = filepath.Clean(path)
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			err = fmt.Errorf("%s is outside of the job directory", path)
+		}
+		return path
+	}
+	options.Graph = inDir(options.Graph)
+	options.GitRepo = inDir(options.GitRepo)
+	options.PreviousState = inDir(options.PreviousState)
+	for i, path := range options.ExcludeManaged {
+		options.ExcludeManaged[i] = inDir(path)
+	}
+	for i, filter := range options.Filter {
+		if strings.HasPrefix(filter, "@") {
+			options.Filter[i] = "@" + inDir(strings.TrimPrefix(filter, "@"))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(options.PathPattern, "{output}") || strings.Contains(options.PathPattern, "..") {
+		return fmt.Errorf("path pattern %s has to start with {output} and can't contain ..", options.PathPattern)
+	}
+	return nil
+}
+
+// joinFlagValues writes values the way string slice flags read them, as a CSV record
+func joinFlagValues(values []string) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(values); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n"), w.Error()
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/server"
+)
+
+func TestJobArgs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "job")
+	request := server.JobRequest{
+		Provider: "github",
+		Options:  json.RawMessage(`{"Resources":["repositories"],"Graph":"graph.dot","Filter":["@filters/repos.txt","repository=terraformer"]}`),
+		Args:     []string{"--owner=GoogleCloudPlatform", "--token=secret"},
+	}
+	args, err := jobArgs(request, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"--filter=@" + filepath.Join(dir, "filters/repos.txt") + ",repository=terraformer",
+		"--graph=" + filepath.Join(dir, "graph.dot"),
+		"--resources=repositories",
+		"--owner=GoogleCloudPlatform",
+		"--token=secret",
+		"--path-output=" + filepath.Join(dir, DefaultPathOutput),
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestJobArgsRejectsPathsOutsideDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "job")
+	for _, options := range []string{
+		`{"Graph":"../graph.dot"}`,
+		`{"GitRepo":"/var/lib/terraform"}`,
+		`{"PreviousState":"../../terraform.tfstate"}`,
+		`{"ExcludeManaged":["states","/etc"]}`,
+		`{"Filter":["@../filters.txt"]}`,
+		`{"PathPattern":"/tmp/{provider}/{service}/"}`,
+		`{"PathPattern":"{output}/../{service}/"}`,
+	} {
+		request := server.JobRequest{Provider: "github", Options: json.RawMessage(options)}
+		if _, err := jobArgs(request, dir); err == nil {
+			t.Errorf("expected options %s to be rejected", options)
+		}
+	}
+}
+
+func TestJobArgsRejectsArgs(t *testing.T) {
+	for _, arg := range []string{
+		"--credentials=/root/.config/gcloud/credentials.json",
+		"--ids=/etc/passwd",
+		"--path-output=/tmp",
+		"--provider-name=aws",
+		"--provider-config={}",
+		"--type=aws_vpc",
+		"-o=/tmp",
+		"owner=GoogleCloudPlatform",
+	} {
+		request := server.JobRequest{Provider: "github", Args: []string{arg}}
+		if _, err := jobArgs(request, t.TempDir()); err == nil {
+			t.Errorf("expected arg %s to be rejected", arg)
+		}
+	}
+}
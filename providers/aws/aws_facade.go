@@ -58,7 +58,20 @@ func (s *AwsFacade) SetResources(resources []terraformutils.Resource) {
 }
 
 func (s *AwsFacade) InitResources() error {
-	err := s.service.InitResources()
+	return skipUnavailableService(s.service.InitResources())
+}
+
+// InitResourcesInBatches discovers page by page when the service does, else it returns terraformutils.ErrNoBatches
+func (s *AwsFacade) InitResourcesInBatches(batch func([]terraformutils.Resource) error) error {
+	batchService, ok := s.service.(terraformutils.BatchService)
+	if !ok {
+		return terraformutils.ErrNoBatches
+	}
+	return skipUnavailableService(batchService.InitResourcesInBatches(batch))
+}
+
+// skipUnavailableService ignores errors of AWS services which aren't available in the region
+func skipUnavailableService(err error) error {
 	if err == nil {
 		return nil
 	}
@@ -15,8 +15,8 @@
 package aws
 
 import (
+	"log"
 	"os"
-	"strconv"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
 	"github.com/pkg/errors"
@@ -27,6 +27,7 @@ type AWSProvider struct { //nolint
 	terraformutils.Provider
 	region  string
 	profile string
+	account string
 }
 
 const GlobalRegion = "aws-global"
@@ -159,17 +160,20 @@ func (p AWSProvider) GetProviderData(arg ...string) map[string]interface{} {
 	}
 }
 
+// GetConfig passes region and profile to the plugin in its config, not in the environment
+// of terraformer, which is shared by all regions imported in parallel
 func (p *AWSProvider) GetConfig() cty.Value {
-	if p.region != GlobalRegion {
-		return cty.ObjectVal(map[string]cty.Value{
-			"region":                 cty.StringVal(p.region),
-			"skip_region_validation": cty.True,
-		})
-	}
-	return cty.ObjectVal(map[string]cty.Value{
-		"region":                 cty.StringVal(""),
+	config := map[string]cty.Value{
+		"region":                 cty.StringVal(p.region),
 		"skip_region_validation": cty.True,
-	})
+	}
+	if p.region == GlobalRegion {
+		config["region"] = cty.StringVal("")
+	}
+	if p.profile != "default" && p.profile != "" {
+		config["profile"] = cty.StringVal(p.profile)
+	}
+	return cty.ObjectVal(config)
 }
 
 func (p *AWSProvider) GetBasicConfig() cty.Value {
@@ -180,32 +184,60 @@ func (p *AWSProvider) GetBasicConfig() cty.Value {
 func (p *AWSProvider) Init(args []string) error {
 	p.region = args[0]
 	p.profile = args[1]
+	return nil
+}
 
-	// Terraformer accepts region and profile configuration, so we must detect what env variables to adjust to make Go SDK rely on them. AWS_SDK_LOAD_CONFIG here must be checked to determine correct variable to set.
-	enableSharedConfig, _ := strconv.ParseBool(os.Getenv("AWS_SDK_LOAD_CONFIG"))
-	var err error
-	if p.region != GlobalRegion && p.region != NoRegion {
-		if enableSharedConfig {
-			err = os.Setenv("AWS_DEFAULT_REGION", p.region)
-		} else {
-			err = os.Setenv("AWS_REGION", p.region)
+func (p *AWSProvider) GetPathAttributes() map[string]string {
+	return p.GetPathAttributesFor([]string{"region", "account"})
+}
+
+// GetPathAttributesFor looks up the account ID with STS only when it is asked for
+func (p *AWSProvider) GetPathAttributesFor(names []string) map[string]string {
+	attributes := map[string]string{}
+	for _, name := range names {
+		switch name {
+		case "region":
+			attributes["region"] = p.pathRegion()
+		case "account":
+			attributes["account"] = p.accountID()
 		}
-		if err != nil {
-			return err
+	}
+	return attributes
+}
+
+func (p *AWSProvider) pathRegion() string {
+	switch p.region {
+	case GlobalRegion:
+		return "global"
+	case NoRegion:
+		if region := os.Getenv("AWS_REGION"); region != "" {
+			return region
 		}
+		return os.Getenv("AWS_DEFAULT_REGION")
 	}
+	return p.region
+}
 
-	if p.profile != "default" && p.profile != "" {
-		if enableSharedConfig {
-			err = os.Setenv("AWS_DEFAULT_PROFILE", p.profile)
-		} else {
-			err = os.Setenv("AWS_PROFILE", p.profile)
+func (p *AWSProvider) accountID() string {
+	if p.account == "" {
+		s := &AWSService{}
+		s.SetArgs(map[string]interface{}{
+			"region":  p.region,
+			"profile": p.profile,
+		})
+		config, err := s.generateConfig()
+		if err == nil {
+			var account *string
+			account, err = s.getAccountNumber(config)
+			if err == nil {
+				p.account = *account
+			}
 		}
 		if err != nil {
-			return err
+			log.Println("aws: can't resolve account ID for path pattern:", err)
 		}
 	}
-	return nil
+	return p.account
 }
 
 func (p *AWSProvider) GetName() string {
@@ -0,0 +1,34 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPathAttributesForRegionOnly(t *testing.T) {
+	// an account lookup would call STS, which fails without credentials and leaves account empty
+	p := &AWSProvider{region: "eu-west-1"}
+	attributes := p.GetPathAttributesFor([]string{"region"})
+	if !reflect.DeepEqual(attributes, map[string]string{"region": "eu-west-1"}) {
+		t.Errorf("unexpected attributes %v", attributes)
+	}
+	p = &AWSProvider{region: GlobalRegion, account: "123456789012"}
+	attributes = p.GetPathAttributesFor([]string{"region", "account"})
+	if !reflect.DeepEqual(attributes, map[string]string{"region": "global", "account": "123456789012"}) {
+		t.Errorf("unexpected attributes %v", attributes)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"os"
 	"regexp"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder"
 )
 
 type AWSService struct { //nolint
@@ -34,11 +36,32 @@ type AWSService struct { //nolint
 
 var awsVariable = regexp.MustCompile(`(\${[0-9A-Za-z:]+})`)
 
-var configCache *aws.Config
+var (
+	configCacheMu sync.Mutex
+	// configs are cached by region and profile, regions may be imported in parallel.
+	// The lock is held while credentials are retrieved, so MFA prompts don't interleave.
+	configCache = map[string]aws.Config{}
+	// profileConfigs hold the credentials cache of a profile, configs of other regions of the
+	// profile are copies with another region, so MFA and assume role prompt once per profile
+	profileConfigs = map[string]aws.Config{}
+)
 
 func (s *AWSService) generateConfig() (aws.Config, error) {
-	if configCache != nil {
-		return *configCache, nil
+	region := s.GetArgs()["region"].(string)
+	profile := s.GetArgs()["profile"].(string)
+	key := region + "/" + profile
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+	if config, exist := configCache[key]; exist {
+		return config, nil
+	}
+	if profileConfig, exist := profileConfigs[profile]; exist {
+		config := profileConfig.Copy()
+		if region != "" {
+			config.Region = region
+		}
+		configCache[key] = config
+		return config, nil
 	}
 
 	baseConfig, e := s.buildBaseConfig()
@@ -49,6 +72,9 @@ func (s *AWSService) generateConfig() (aws.Config, error) {
 	if s.Verbose {
 		baseConfig.ClientLogMode = aws.LogRequestWithBody & aws.LogResponseWithBody
 	}
+	if _, ok := baseConfig.Credentials.(*aws.CredentialsCache); !ok && baseConfig.Credentials != nil {
+		baseConfig.Credentials = aws.NewCredentialsCache(baseConfig.Credentials)
+	}
 
 	creds, e := baseConfig.Credentials.Retrieve(context.TODO())
 
@@ -66,7 +92,8 @@ func (s *AWSService) generateConfig() (aws.Config, error) {
 			os.Setenv("AWS_SESSION_TOKEN", creds.SessionToken)
 		}
 	}
-	configCache = &baseConfig
+	profileConfigs[profile] = baseConfig
+	configCache[key] = baseConfig
 	return baseConfig, nil
 }
 
@@ -76,7 +103,10 @@ func (s *AWSService) buildBaseConfig() (aws.Config, error) {
 		loadOptions = append(loadOptions, config.WithSharedConfigProfile(s.GetArgs()["profile"].(string)))
 	}
 	if s.GetArgs()["region"].(string) != "" {
-		os.Setenv("AWS_REGION", s.GetArgs()["region"].(string))
+		loadOptions = append(loadOptions, config.WithRegion(s.GetArgs()["region"].(string)))
+	}
+	if recorder.Active() != nil {
+		loadOptions = append(loadOptions, config.WithHTTPClient(recorder.HTTPClient()))
 	}
 	loadOptions = append(loadOptions, config.WithAssumeRoleCredentialOptions(func(options *stscreds.AssumeRoleOptions) {
 		options.TokenProvider = stscreds.StdinTokenProvider
@@ -97,3 +127,15 @@ func (s *AWSService) getAccountNumber(config aws.Config) (*string, error) {
 	}
 	return identity.Account, nil
 }
+
+// PrepareCredentials retrieves the credentials of profile before plugins are launched.
+// Plugins are started with the environment of terraformer, which holds the STS session token afterwards.
+func PrepareCredentials(region, profile string) error {
+	s := &AWSService{}
+	s.SetArgs(map[string]interface{}{
+		"region":  region,
+		"profile": profile,
+	})
+	_, err := s.generateConfig()
+	return err
+}
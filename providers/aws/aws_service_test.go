@@ -0,0 +1,135 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// resetConfigCache empties the caches before and after the test, configs of tests don't replay cassettes
+func resetConfigCache(t *testing.T) {
+	reset := func() {
+		configCacheMu.Lock()
+		configCache = map[string]aws.Config{}
+		profileConfigs = map[string]aws.Config{}
+		configCacheMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestConfigCacheKeyedByRegionAndProfile(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	content := "[default]\naws_access_key_id = AKIADEFAULT\naws_secret_access_key = default\n" +
+		"[dev]\naws_access_key_id = AKIADEV\naws_secret_access_key = dev\n"
+	if err := ioutil.WriteFile(credentials, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	// PrepareCredentials exports the credentials, t.Setenv restores the variables afterwards
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	// configs of the test don't replay cassettes, later tests start with an empty cache
+	resetConfigCache(t)
+
+	keys := [][2]string{{"eu-west-1", ""}, {"us-east-1", ""}, {"eu-west-1", "dev"}}
+	var wg sync.WaitGroup
+	// every region and profile is prepared three times at once
+	errs := make([]error, 3*len(keys))
+	for i := range errs {
+		wg.Add(1)
+		go func(i int, region, profile string) {
+			defer wg.Done()
+			errs[i] = PrepareCredentials(region, profile)
+		}(i, keys[i%len(keys)][0], keys[i%len(keys)][1])
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+	if len(configCache) != len(keys) {
+		t.Errorf("expected a config per region and profile, got %d", len(configCache))
+	}
+	for _, key := range keys {
+		config, exist := configCache[key[0]+"/"+key[1]]
+		if !exist {
+			t.Errorf("no config for region %s and profile %q", key[0], key[1])
+			continue
+		}
+		if config.Region != key[0] {
+			t.Errorf("config of %s has region %s", key[0], config.Region)
+		}
+	}
+}
+
+func TestCredentialsRetrievedOncePerProfile(t *testing.T) {
+	dir := t.TempDir()
+	retrievals := filepath.Join(dir, "retrievals")
+	process := filepath.Join(dir, "credentials.sh")
+	script := "#!/bin/sh\necho retrieved >> " + retrievals + "\n" +
+		`echo '{"Version": 1, "AccessKeyId": "AKIAPROCESS", "SecretAccessKey": "process"}'` + "\n"
+	if err := ioutil.WriteFile(process, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	credentials := filepath.Join(dir, "credentials")
+	if err := ioutil.WriteFile(credentials, []byte("[mfa]\ncredential_process = "+process+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_PROFILE", "")
+	resetConfigCache(t)
+
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		// credentials exported by the first region mustn't hide the retrieval of the second one
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_SESSION_TOKEN", "")
+		if err := PrepareCredentials(region, "mfa"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := ioutil.ReadFile(retrievals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := strings.Count(string(data), "retrieved"); count != 1 {
+		t.Errorf("expected credentials to be retrieved once, got %d retrievals", count)
+	}
+
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+	for _, region := range []string{"eu-west-1", "us-east-1"} {
+		if config := configCache[region+"/mfa"]; config.Region != region {
+			t.Errorf("config of %s has region %s", region, config.Region)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/cmd"
+	"github.com/GoogleCloudPlatform/terraformer/providers/aws"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder/recordertest"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// The cassette is a hand-written fixture, TERRAFORMER_RECORD=1 records it with the default profile
+func TestReplayDynamoDB(t *testing.T) {
+	if os.Getenv(recordertest.RecordEnv) == "" {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIAREPLAY")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "replay")
+		t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+		t.Setenv("AWS_CA_BUNDLE", "")
+	}
+	recordertest.Run(t, "testdata/replay/cassette.json", "testdata/replay/golden", func(output string) error {
+		options := cmd.NewImportOptions()
+		options.Resources = []string{"dynamodb"}
+		options.PathOutput = output
+		options.Connect = false
+		return cmd.Import(&aws.AWSProvider{}, options, []string{"eu-west-1", ""})
+	})
+}
+
+// Batches of Route 53 are pages of records with their zone, so records reference their zone like
+// they do without batches. The cassette is a hand-written fixture.
+func TestReplayRoute53Batches(t *testing.T) {
+	if os.Getenv(recordertest.RecordEnv) == "" {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIAREPLAY")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "replay")
+		t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+		t.Setenv("AWS_CA_BUNDLE", "")
+	}
+	importRoute53 := func(output string, batchSize int) error {
+		options := cmd.NewImportOptions()
+		options.Resources = []string{"route53"}
+		options.PathOutput = output
+		options.Connect = false
+		options.BatchSize = batchSize
+		return cmd.Import(&aws.AWSProvider{}, options, []string{"eu-west-1", ""})
+	}
+	var expected string
+	recordertest.Run(t, "testdata/replay_route53/cassette.json", "testdata/replay_route53/golden", func(output string) error {
+		expected = output
+		return importRoute53(output, 0)
+	})
+
+	importBatches := func(batchSize int) string {
+		r, err := recorder.Start("testdata/replay_route53/cassette.json", recorder.Replay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		generated := t.TempDir()
+		err = importRoute53(generated, batchSize)
+		if stopErr := r.Stop(); stopErr != nil {
+			t.Fatal(stopErr)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return generated
+	}
+
+	// records of the zone Z0EXAMPLE1 are two pages, a page of a zone and a record fits into batches of 2.
+	// Batches append in order of discovery, blocks and resources of the state are the same.
+	dir := filepath.Join("aws", "route53")
+	generated := importBatches(2)
+	for _, name := range []string{"outputs.tf", "provider.tf", "route53_record.tf", "route53_zone.tf"} {
+		expectedBlocks := readBlocks(t, filepath.Join(expected, dir, name))
+		if generatedBlocks := readBlocks(t, filepath.Join(generated, dir, name)); !reflect.DeepEqual(expectedBlocks, generatedBlocks) {
+			t.Errorf("%s differs\nexpected:\n%v\ngenerated:\n%v", name, expectedBlocks, generatedBlocks)
+		}
+	}
+	expectedState := readStateResources(t, filepath.Join(expected, dir, "terraform.tfstate"))
+	if generatedState := readStateResources(t, filepath.Join(generated, dir, "terraform.tfstate")); !reflect.DeepEqual(expectedState, generatedState) {
+		t.Errorf("terraform.tfstate differs\nexpected:\n%v\ngenerated:\n%v", expectedState, generatedState)
+	}
+
+	// larger pages are split by type, records reference the ID of their zone
+	generated = importBatches(1)
+	expectedBlocks := readBlocks(t, filepath.Join(expected, dir, "route53_record.tf"))
+	generatedBlocks := readBlocks(t, filepath.Join(generated, dir, "route53_record.tf"))
+	if len(generatedBlocks) != len(expectedBlocks) {
+		t.Errorf("expected %d records, got %v", len(expectedBlocks), generatedBlocks)
+	}
+	for key, block := range generatedBlocks {
+		if !strings.Contains(block, `zone_id = "Z0EXAMPLE`) {
+			t.Errorf("expected %s to reference the ID of its zone, got %s", key, block)
+		}
+	}
+	if generatedState := readStateResources(t, filepath.Join(generated, dir, "terraform.tfstate")); len(generatedState) != len(expectedState) {
+		t.Errorf("expected %d resources in terraform.tfstate, got %v", len(expectedState), generatedState)
+	}
+}
+
+// readBlocks returns the source of the blocks of the file by their type and labels
+func readBlocks(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	blocks := map[string]string{}
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		key := strings.Join(append([]string{block.Type}, block.Labels...), ".")
+		blocks[key] = string(block.Range().SliceBytes(data))
+	}
+	return blocks
+}
+
+func readStateResources(t *testing.T, path string) map[string]map[string]string {
+	t.Helper()
+	resources, err := terraformutils.ReadStateResources(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := map[string]map[string]string{}
+	for _, r := range resources {
+		attributes[r.Address()] = r.Attributes
+	}
+	return attributes
+}
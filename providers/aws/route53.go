@@ -34,18 +34,19 @@ type Route53Generator struct {
 	AWSService
 }
 
-func (g *Route53Generator) createZonesResources(svc *route53.Client) []terraformutils.Resource {
-	var resources []terraformutils.Resource
+// createZonesResources passes every page of records of a zone with the zone to batch, so records
+// reference their zone in every batch. Zones without records are passed alone.
+func (g *Route53Generator) createZonesResources(svc *route53.Client, batch func([]terraformutils.Resource) error) error {
 	p := route53.NewListHostedZonesPaginator(svc, &route53.ListHostedZonesInput{})
 	for p.HasMorePages() {
 		page, err := p.NextPage(context.TODO())
 		if err != nil {
 			log.Println(err)
-			return resources
+			return nil
 		}
 		for _, zone := range page.HostedZones {
 			zoneID := cleanZoneID(StringValue(zone.Id))
-			resources = append(resources, terraformutils.NewResource(
+			zoneResource := terraformutils.NewResource(
 				zoneID,
 				zoneID+"_"+strings.TrimSuffix(StringValue(zone.Name), "."),
 				"aws_route53_zone",
@@ -56,16 +57,27 @@ func (g *Route53Generator) createZonesResources(svc *route53.Client) []terraform
 				},
 				route53AllowEmptyValues,
 				route53AdditionalFields,
-			))
-			records := g.createRecordsResources(svc, zoneID)
-			resources = append(resources, records...)
+			)
+			hasRecords := false
+			err := g.createRecordsResources(svc, zoneID, func(records []terraformutils.Resource) error {
+				hasRecords = true
+				return batch(append([]terraformutils.Resource{zoneResource}, records...))
+			})
+			if err != nil {
+				return err
+			}
+			if !hasRecords {
+				if err := batch([]terraformutils.Resource{zoneResource}); err != nil {
+					return err
+				}
+			}
 		}
 	}
-	return resources
+	return nil
 }
 
-func (Route53Generator) createRecordsResources(svc *route53.Client, zoneID string) []terraformutils.Resource {
-	var resources []terraformutils.Resource
+// createRecordsResources passes the records of the zone to batch page by page
+func (Route53Generator) createRecordsResources(svc *route53.Client, zoneID string, batch func([]terraformutils.Resource) error) error {
 	var sets *route53.ListResourceRecordSetsOutput
 	var err error
 	listParams := &route53.ListResourceRecordSetsInput{
@@ -76,8 +88,9 @@ func (Route53Generator) createRecordsResources(svc *route53.Client, zoneID strin
 		sets, err = svc.ListResourceRecordSets(context.TODO(), listParams)
 		if err != nil {
 			log.Println(err)
-			return resources
+			return nil
 		}
+		var resources []terraformutils.Resource
 		for _, record := range sets.ResourceRecordSets {
 			recordName := wildcardUnescape(StringValue(record.Name))
 			typeString := string(record.Type)
@@ -96,6 +109,11 @@ func (Route53Generator) createRecordsResources(svc *route53.Client, zoneID strin
 				route53AdditionalFields,
 			))
 		}
+		if len(resources) > 0 {
+			if err := batch(resources); err != nil {
+				return err
+			}
+		}
 
 		if sets.IsTruncated {
 			listParams.StartRecordName = sets.NextRecordName
@@ -105,7 +123,7 @@ func (Route53Generator) createRecordsResources(svc *route53.Client, zoneID strin
 			break
 		}
 	}
-	return resources
+	return nil
 }
 
 // Generate TerraformResources from AWS API,
@@ -117,8 +135,31 @@ func (g *Route53Generator) InitResources() error {
 	}
 	svc := route53.NewFromConfig(config)
 
-	g.Resources = g.createZonesResources(svc)
-	return nil
+	g.Resources = []terraformutils.Resource{}
+	// zones come with every page of their records, they are only kept once
+	zones := map[string]bool{}
+	return g.createZonesResources(svc, func(resources []terraformutils.Resource) error {
+		for _, r := range resources {
+			if r.InstanceInfo.Type == "aws_route53_zone" {
+				if zones[r.InstanceState.ID] {
+					continue
+				}
+				zones[r.InstanceState.ID] = true
+			}
+			g.Resources = append(g.Resources, r)
+		}
+		return nil
+	})
+}
+
+// InitResourcesInBatches passes every page of records with their zone to batch, accounts and
+// zones with many records don't have to be held in memory at once
+func (g *Route53Generator) InitResourcesInBatches(batch func([]terraformutils.Resource) error) error {
+	config, e := g.generateConfig()
+	if e != nil {
+		return e
+	}
+	return g.createZonesResources(route53.NewFromConfig(config), batch)
 }
 
 func (g *Route53Generator) PostConvertHook() error {
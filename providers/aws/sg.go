@@ -86,14 +86,19 @@ func (SecurityGenerator) createResources(securityGroups []types.SecurityGroup) [
 			}
 		}
 
-		resources = append(resources, terraformutils.NewResource(
+		resource := terraformutils.NewResource(
 			StringValue(sg.GroupId),
 			strings.Trim(StringValue(sg.GroupName)+"_"+StringValue(sg.GroupId), " "),
 			"aws_security_group",
 			"aws",
 			map[string]string{},
 			SgAllowEmptyValues,
-			ruleAttributes))
+			ruleAttributes)
+		// every VPC has one, it can't be deleted
+		if StringValue(sg.GroupName) == "default" {
+			resource.ManagedBy = "AWS, default security group"
+		}
+		resources = append(resources, resource)
 	}
 	return resources
 }
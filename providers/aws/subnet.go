@@ -38,6 +38,9 @@ func (SubnetGenerator) createResources(subnets *ec2.DescribeSubnetsOutput) []ter
 			SubnetAllowEmptyValues,
 		)
 		resource.IgnoreKeys = append(resource.IgnoreKeys, "availability_zone")
+		if subnet.DefaultForAz {
+			resource.ManagedBy = "AWS, default subnet"
+		}
 		resources = append(resources, resource)
 	}
 	return resources
@@ -0,0 +1,58 @@
+resource "aws_dynamodb_table" "tfer--orders" {
+  attribute {
+    name = "customer_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "order_id"
+    type = "S"
+  }
+
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "customer_id"
+  name         = "orders"
+
+  point_in_time_recovery {
+    enabled = "true"
+  }
+
+  range_key      = "order_id"
+  read_capacity  = "0"
+  stream_enabled = "false"
+
+  tags = {
+    team = "checkout"
+  }
+
+  tags_all = {
+    team = "checkout"
+  }
+
+  write_capacity = "0"
+}
+
+resource "aws_dynamodb_table" "tfer--sessions" {
+  attribute {
+    name = "session_id"
+    type = "S"
+  }
+
+  billing_mode = "PROVISIONED"
+  hash_key     = "session_id"
+  name         = "sessions"
+
+  point_in_time_recovery {
+    enabled = "false"
+  }
+
+  read_capacity  = "5"
+  stream_enabled = "false"
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = "true"
+  }
+
+  write_capacity = "5"
+}
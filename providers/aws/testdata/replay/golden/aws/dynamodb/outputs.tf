@@ -0,0 +1,7 @@
+output "aws_dynamodb_table_tfer--orders_id" {
+  value = "${aws_dynamodb_table.tfer--orders.id}"
+}
+
+output "aws_dynamodb_table_tfer--sessions_id" {
+  value = "${aws_dynamodb_table.tfer--sessions.id}"
+}
@@ -0,0 +1,19 @@
+output "aws_route53_record_tfer--Z0EXAMPLE1_api-002E-example-002E-com-002E-_CNAME__id" {
+  value = "${aws_route53_record.tfer--Z0EXAMPLE1_api-002E-example-002E-com-002E-_CNAME_.id}"
+}
+
+output "aws_route53_record_tfer--Z0EXAMPLE1_www-002E-example-002E-com-002E-_A__id" {
+  value = "${aws_route53_record.tfer--Z0EXAMPLE1_www-002E-example-002E-com-002E-_A_.id}"
+}
+
+output "aws_route53_record_tfer--Z0EXAMPLE2_db-002E-internal-002E-example-002E-_A__id" {
+  value = "${aws_route53_record.tfer--Z0EXAMPLE2_db-002E-internal-002E-example-002E-_A_.id}"
+}
+
+output "aws_route53_zone_tfer--Z0EXAMPLE1_example-002E-com_id" {
+  value = "${aws_route53_zone.tfer--Z0EXAMPLE1_example-002E-com.id}"
+}
+
+output "aws_route53_zone_tfer--Z0EXAMPLE2_internal-002E-example_id" {
+  value = "${aws_route53_zone.tfer--Z0EXAMPLE2_internal-002E-example.id}"
+}
@@ -0,0 +1,12 @@
+provider "aws" {
+  region = "eu-west-1"
+}
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 3.74.0"
+    }
+  }
+}
@@ -0,0 +1,23 @@
+resource "aws_route53_record" "tfer--Z0EXAMPLE1_api-002E-example-002E-com-002E-_CNAME_" {
+  name    = "api.example.com"
+  records = ["www.example.com"]
+  ttl     = "60"
+  type    = "CNAME"
+  zone_id = "${aws_route53_zone.tfer--Z0EXAMPLE1_example-002E-com.zone_id}"
+}
+
+resource "aws_route53_record" "tfer--Z0EXAMPLE1_www-002E-example-002E-com-002E-_A_" {
+  name    = "www.example.com"
+  records = ["192.0.2.10"]
+  ttl     = "300"
+  type    = "A"
+  zone_id = "${aws_route53_zone.tfer--Z0EXAMPLE1_example-002E-com.zone_id}"
+}
+
+resource "aws_route53_record" "tfer--Z0EXAMPLE2_db-002E-internal-002E-example-002E-_A_" {
+  name    = "db.internal.example"
+  records = ["10.0.0.5"]
+  ttl     = "300"
+  type    = "A"
+  zone_id = "${aws_route53_zone.tfer--Z0EXAMPLE2_internal-002E-example.zone_id}"
+}
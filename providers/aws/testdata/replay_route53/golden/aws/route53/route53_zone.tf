@@ -0,0 +1,11 @@
+resource "aws_route53_zone" "tfer--Z0EXAMPLE1_example-002E-com" {
+  comment       = "Managed by Terraform"
+  force_destroy = "false"
+  name          = "example.com"
+}
+
+resource "aws_route53_zone" "tfer--Z0EXAMPLE2_internal-002E-example" {
+  comment       = "Managed by Terraform"
+  force_destroy = "false"
+  name          = "internal.example"
+}
@@ -31,13 +31,17 @@ type VpcGenerator struct {
 func (VpcGenerator) createResources(vpcs *ec2.DescribeVpcsOutput) []terraformutils.Resource {
 	var resources []terraformutils.Resource
 	for _, vpc := range vpcs.Vpcs {
-		resources = append(resources, terraformutils.NewSimpleResource(
+		resource := terraformutils.NewSimpleResource(
 			StringValue(vpc.VpcId),
 			StringValue(vpc.VpcId),
 			"aws_vpc",
 			"aws",
 			VpcAllowEmptyValues,
-		))
+		)
+		if vpc.IsDefault {
+			resource.ManagedBy = "AWS, default VPC"
+		}
+		resources = append(resources, resource)
 	}
 	return resources
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/hashicorp/go-azure-helpers/authentication"
 	"github.com/hashicorp/go-azure-helpers/sender"
+	"github.com/zclconf/go-cty/cty"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
@@ -35,8 +36,10 @@ type AzureProvider struct { //nolint
 	resourceGroup string
 }
 
-func (p *AzureProvider) setEnvConfig() error {
-	subscriptionID := os.Getenv("ARM_SUBSCRIPTION_ID")
+func (p *AzureProvider) setEnvConfig(subscriptionID string) error {
+	if subscriptionID == "" {
+		subscriptionID = os.Getenv("ARM_SUBSCRIPTION_ID")
+	}
 	if subscriptionID == "" {
 		return errors.New("set ARM_SUBSCRIPTION_ID env var")
 	}
@@ -96,8 +99,13 @@ func (p *AzureProvider) getAuthorizer() (autorest.Authorizer, error) {
 	return auth, nil
 }
 
+// Init uses the subscription of the second argument when given, ARM_SUBSCRIPTION_ID otherwise
 func (p *AzureProvider) Init(args []string) error {
-	err := p.setEnvConfig()
+	subscriptionID := ""
+	if len(args) > 1 {
+		subscriptionID = args[1]
+	}
+	err := p.setEnvConfig(subscriptionID)
 	if err != nil {
 		return err
 	}
@@ -112,6 +120,32 @@ func (p *AzureProvider) Init(args []string) error {
 	return nil
 }
 
+// GetConfig configures the plugin with the subscription of the provider, so imports of several
+// subscriptions don't share a plugin reading everything from ARM_SUBSCRIPTION_ID
+func (p *AzureProvider) GetConfig() cty.Value {
+	config := map[string]cty.Value{}
+	for name, value := range map[string]string{
+		"subscription_id": p.config.SubscriptionID,
+		"tenant_id":       p.config.TenantID,
+		"client_id":       p.config.ClientID,
+	} {
+		if value != "" {
+			config[name] = cty.StringVal(value)
+		}
+	}
+	return cty.ObjectVal(config)
+}
+
+func (p *AzureProvider) GetBasicConfig() cty.Value {
+	return p.GetConfig()
+}
+
+func (p *AzureProvider) GetPathAttributes() map[string]string {
+	return map[string]string{
+		"account": p.config.SubscriptionID,
+	}
+}
+
 func (p *AzureProvider) GetName() string {
 	return "azurerm"
 }
@@ -0,0 +1,49 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGetConfigOfSubscriptions(t *testing.T) {
+	t.Setenv("ARM_SUBSCRIPTION_ID", "00000000-0000-0000-0000-000000000000")
+	t.Setenv("ARM_CLIENT_ID", "11111111-1111-1111-1111-111111111111")
+	t.Setenv("ARM_CLIENT_SECRET", "secret")
+	t.Setenv("ARM_TENANT_ID", "22222222-2222-2222-2222-222222222222")
+	t.Setenv("ARM_ENVIRONMENT", "public")
+
+	// every alias of --subscriptions initializes a provider with its subscription
+	var configs []cty.Value
+	for _, subscription := range []string{"aaaaaaaa-0000-0000-0000-000000000000", "bbbbbbbb-0000-0000-0000-000000000000"} {
+		p := &AzureProvider{}
+		if err := p.setEnvConfig(subscription); err != nil {
+			t.Fatal(err)
+		}
+		config := p.GetConfig()
+		if id := config.GetAttr("subscription_id"); !id.RawEquals(cty.StringVal(subscription)) {
+			t.Errorf("expected subscription %s in the plugin config, got %#v", subscription, id)
+		}
+		if !config.GetAttr("tenant_id").RawEquals(cty.StringVal("22222222-2222-2222-2222-222222222222")) {
+			t.Errorf("missing tenant in %#v", config)
+		}
+		configs = append(configs, config)
+	}
+	if configs[0].RawEquals(configs[1]) {
+		t.Error("subscriptions share a plugin config")
+	}
+}
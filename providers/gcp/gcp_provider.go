@@ -76,6 +76,17 @@ func (p *GCPProvider) Init(args []string) error {
 	return nil
 }
 
+func (p *GCPProvider) GetPathAttributes() map[string]string {
+	region := p.region.Name
+	if region == "" {
+		region = "global"
+	}
+	return map[string]string{
+		"project": p.projectName,
+		"region":  region,
+	}
+}
+
 func (p *GCPProvider) GetName() string {
 	if p.providerType != "" {
 		return "google-" + p.providerType
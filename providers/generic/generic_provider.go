@@ -0,0 +1,122 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// GenericProvider imports resources of any type of a provider plugin by their IDs
+type GenericProvider struct { //nolint
+	terraformutils.Provider
+	providerName string
+	config       map[string]string
+	entries      map[string][]Entry
+}
+
+// Init expects the provider name, the IDs file, the type of IDs without type
+// and provider configuration as key=value
+func (p *GenericProvider) Init(args []string) error {
+	if len(args) < 3 || args[0] == "" {
+		return errors.New("generic: provider name and IDs file are required")
+	}
+	p.providerName = args[0]
+	p.config = map[string]string{}
+	for _, pair := range args[3:] {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return errors.New("generic: provider config " + pair + " is not key=value")
+		}
+		p.config[parts[0]] = parts[1]
+	}
+	entries, err := ReadEntries(args[1], args[2])
+	if err != nil {
+		return err
+	}
+	p.entries = map[string][]Entry{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Type, p.providerName+"_") {
+			return errors.New("generic: " + entry.Type + " is not a resource type of provider " + p.providerName)
+		}
+		service := p.serviceName(entry.Type)
+		p.entries[service] = append(p.entries[service], entry)
+	}
+	return nil
+}
+
+func (p *GenericProvider) serviceName(resourceType string) string {
+	return strings.TrimPrefix(resourceType, p.providerName+"_")
+}
+
+func (p *GenericProvider) GetName() string {
+	return p.providerName
+}
+
+// GetProviderData writes the provider configuration given as key=value into the provider block
+func (p *GenericProvider) GetProviderData(arg ...string) map[string]interface{} {
+	config := map[string]interface{}{}
+	for k, v := range p.config {
+		config[k] = v
+	}
+	return map[string]interface{}{
+		"provider": map[string]interface{}{
+			p.providerName: config,
+		},
+	}
+}
+
+func (p *GenericProvider) GetConfig() cty.Value {
+	if len(p.config) == 0 {
+		return cty.EmptyObjectVal
+	}
+	config := map[string]cty.Value{}
+	for k, v := range p.config {
+		config[k] = cty.StringVal(v)
+	}
+	return cty.ObjectVal(config)
+}
+
+func (p *GenericProvider) GetBasicConfig() cty.Value {
+	return p.GetConfig()
+}
+
+func (p *GenericProvider) InitService(serviceName string, verbose bool) error {
+	var isSupported bool
+	if _, isSupported = p.GetSupportedService()[serviceName]; !isSupported {
+		return errors.New(p.GetName() + ": " + serviceName + " not in IDs file")
+	}
+	p.Service = p.GetSupportedService()[serviceName]
+	p.Service.SetName(serviceName)
+	p.Service.SetVerbose(verbose)
+	p.Service.SetProviderName(p.GetName())
+	return nil
+}
+
+// GetSupportedService returns a service per resource type of the IDs file
+func (p *GenericProvider) GetSupportedService() map[string]terraformutils.ServiceGenerator {
+	services := map[string]terraformutils.ServiceGenerator{}
+	for service, entries := range p.entries {
+		services[service] = &GenericGenerator{entries: entries}
+	}
+	return services
+}
+
+func (GenericProvider) GetResourceConnections() map[string]map[string][]string {
+	return map[string]map[string][]string{}
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// Entry is a resource to import, the name defaults to the ID
+type Entry struct {
+	Type string
+	ID   string
+	Name string
+}
+
+type GenericGenerator struct { //nolint
+	terraformutils.Service
+	entries []Entry
+}
+
+func (g *GenericGenerator) InitResources() error {
+	for _, entry := range g.entries {
+		resource := terraformutils.NewSimpleResource(
+			entry.ID,
+			entry.Name,
+			entry.Type,
+			g.ProviderName,
+			[]string{},
+		)
+		resource.ImportRequired = true
+		g.Resources = append(g.Resources, resource)
+	}
+	return nil
+}
+
+// ReadEntries reads one ID of resourceType per line, or type,id[,name] records
+// of a .csv file where an empty type falls back to resourceType.
+// Empty lines and lines starting with # are skipped.
+func ReadEntries(path, resourceType string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		return readIDs(f, path, resourceType)
+	}
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected type,id[,name]", path, line)
+		}
+		if len(entries) == 0 && record[0] == "type" && record[1] == "id" {
+			continue // header
+		}
+		entry := Entry{Type: record[0], ID: record[1]}
+		if entry.Type == "" {
+			entry.Type = resourceType
+		}
+		if len(record) == 3 {
+			entry.Name = record[2]
+		}
+		if entry.ID == "" {
+			continue
+		}
+		if entry.Type == "" {
+			return nil, fmt.Errorf("%s:%d: no resource type for %s, use --type", path, line, entry.ID)
+		}
+		entries = append(entries, entry.withDefaultName())
+	}
+	return entries, nil
+}
+
+func readIDs(r io.Reader, path, resourceType string) ([]Entry, error) {
+	if resourceType == "" {
+		return nil, fmt.Errorf("%s: --type is required for files with IDs only", path)
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") {
+			continue
+		}
+		entries = append(entries, Entry{Type: resourceType, ID: id}.withDefaultName())
+	}
+	return entries, scanner.Err()
+}
+
+func (e Entry) withDefaultName() Entry {
+	if e.Name == "" {
+		e.Name = e.ID
+	}
+	return e
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadEntriesCSV(t *testing.T) {
+	entries, err := ReadEntries("testdata/queues.csv", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Entry{
+		{Type: "rabbitmq_queue", ID: "orders@/", Name: "orders"},
+		{Type: "rabbitmq_queue", ID: "payments,retry@/", Name: "payments,retry@/"},
+		{Type: "rabbitmq_vhost", ID: "orders", Name: "orders"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("got %v, expected %v", entries, expected)
+	}
+}
+
+func TestReadEntriesIDs(t *testing.T) {
+	if _, err := ReadEntries("testdata/vhosts.txt", ""); err == nil {
+		t.Error("expected error without resource type")
+	}
+	entries, err := ReadEntries("testdata/vhosts.txt", "rabbitmq_vhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Entry{
+		{Type: "rabbitmq_vhost", ID: "orders", Name: "orders"},
+		{Type: "rabbitmq_vhost", ID: "/", Name: "/"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("got %v, expected %v", entries, expected)
+	}
+}
+
+func TestGetProviderData(t *testing.T) {
+	p := &GenericProvider{}
+	if err := p.Init([]string{"rabbitmq", "testdata/vhosts.txt", "rabbitmq_vhost", "endpoint=http://localhost:15672", "insecure=true"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"provider": map[string]interface{}{
+			"rabbitmq": map[string]interface{}{
+				"endpoint": "http://localhost:15672",
+				"insecure": "true",
+			},
+		},
+	}
+	if data := p.GetProviderData(); !reflect.DeepEqual(data, expected) {
+		t.Errorf("got %v, expected %v", data, expected)
+	}
+}
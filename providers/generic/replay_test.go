@@ -0,0 +1,33 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/cmd"
+	"github.com/GoogleCloudPlatform/terraformer/providers/generic"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder/recordertest"
+)
+
+func TestReplayImportByID(t *testing.T) {
+	recordertest.Run(t, "testdata/replay/cassette.json", "testdata/replay/golden", func(output string) error {
+		options := cmd.NewImportOptions()
+		options.Resources = []string{"*"}
+		options.PathOutput = output
+		options.Connect = false
+		return cmd.Import(&generic.GenericProvider{}, options, []string{"rabbitmq", "testdata/queues.csv", ""})
+	})
+}
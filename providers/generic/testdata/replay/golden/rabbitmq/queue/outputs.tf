@@ -0,0 +1,7 @@
+output "rabbitmq_queue_tfer--orders_id" {
+  value = "${rabbitmq_queue.tfer--orders.id}"
+}
+
+output "rabbitmq_queue_tfer--payments-002C-retry-0040--002F-_id" {
+  value = "${rabbitmq_queue.tfer--payments-002C-retry-0040--002F-.id}"
+}
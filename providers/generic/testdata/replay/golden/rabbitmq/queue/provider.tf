@@ -0,0 +1,10 @@
+provider "rabbitmq" {}
+
+terraform {
+  required_providers {
+    rabbitmq = {
+      source  = "cyrilgdn/rabbitmq"
+      version = "~> 1.5.1"
+    }
+  }
+}
@@ -0,0 +1,25 @@
+resource "rabbitmq_queue" "tfer--orders" {
+  name = "orders"
+
+  settings {
+    arguments = {
+      x-queue-type = "quorum"
+    }
+
+    auto_delete = "false"
+    durable     = "true"
+  }
+
+  vhost = "/"
+}
+
+resource "rabbitmq_queue" "tfer--payments-002C-retry-0040--002F-" {
+  name = "payments,retry"
+
+  settings {
+    auto_delete = "true"
+    durable     = "false"
+  }
+
+  vhost = "/"
+}
@@ -0,0 +1,3 @@
+output "rabbitmq_vhost_tfer--orders_id" {
+  value = "${rabbitmq_vhost.tfer--orders.id}"
+}
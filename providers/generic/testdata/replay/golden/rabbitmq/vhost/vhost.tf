@@ -0,0 +1,3 @@
+resource "rabbitmq_vhost" "tfer--orders" {
+  name = "orders"
+}
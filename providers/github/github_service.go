@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder"
 	"github.com/google/go-github/v35/github"
 	"golang.org/x/oauth2"
 )
@@ -36,7 +37,7 @@ func (g *GithubService) createClient() (*github.Client, error) {
 }
 
 func (g *GithubService) createRegularClient() *github.Client {
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, recorder.HTTPClient())
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: g.Args["token"].(string)},
 	)
@@ -45,7 +46,7 @@ func (g *GithubService) createRegularClient() *github.Client {
 }
 
 func (g *GithubService) createEnterpriseClient() (*github.Client, error) {
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, recorder.HTTPClient())
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: g.Args["token"].(string)},
 	)
@@ -0,0 +1,39 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/cmd"
+	"github.com/GoogleCloudPlatform/terraformer/providers/github"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder/recordertest"
+)
+
+// The cassette is a hand-written fixture, TERRAFORMER_RECORD=1 records it with GITHUB_TOKEN of an owner of the acme-platform organization
+func TestReplayOrganizationBlocks(t *testing.T) {
+	token := "replay"
+	if os.Getenv(recordertest.RecordEnv) != "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	recordertest.Run(t, "testdata/replay/cassette.json", "testdata/replay/golden", func(output string) error {
+		options := cmd.NewImportOptions()
+		options.Resources = []string{"organization_blocks"}
+		options.PathOutput = output
+		options.Connect = false
+		return cmd.Import(&github.GithubProvider{}, options, []string{"acme-platform", token, "https://api.github.com/"})
+	})
+}
@@ -0,0 +1,7 @@
+resource "github_organization_block" "tfer--noisy-crawler" {
+  username = "noisy-crawler"
+}
+
+resource "github_organization_block" "tfer--spam-bot" {
+  username = "spam-bot"
+}
@@ -0,0 +1,7 @@
+output "github_organization_block_tfer--noisy-crawler_id" {
+  value = "${github_organization_block.tfer--noisy-crawler.id}"
+}
+
+output "github_organization_block_tfer--spam-bot_id" {
+  value = "${github_organization_block.tfer--spam-bot.id}"
+}
@@ -0,0 +1,12 @@
+provider "github" {
+  owner = "acme-platform"
+}
+
+terraform {
+  required_providers {
+    github = {
+      source  = "integrations/github"
+      version = "~> 4.9.2"
+    }
+  }
+}
@@ -38,13 +38,23 @@ func (g *ProjectGenerator) InitResources() error {
 	}
 
 	group := g.Args["group"].(string)
-	g.Resources = append(g.Resources, createProjects(ctx, client, group)...)
+	return createProjects(ctx, client, group, func(resources []terraformutils.Resource) error {
+		g.Resources = append(g.Resources, resources...)
+		return nil
+	})
+}
 
-	return nil
+// InitResourcesInBatches passes every page of projects with their variables, protections
+// and members to batch
+func (g *ProjectGenerator) InitResourcesInBatches(batch func([]terraformutils.Resource) error) error {
+	client, err := g.createClient()
+	if err != nil {
+		return err
+	}
+	return createProjects(context.Background(), client, g.Args["group"].(string), batch)
 }
 
-func createProjects(ctx context.Context, client *gitlab.Client, group string) []terraformutils.Resource {
-	resources := []terraformutils.Resource{}
+func createProjects(ctx context.Context, client *gitlab.Client, group string, batch func([]terraformutils.Resource) error) error {
 	opt := &gitlab.ListGroupProjectsOptions{
 		ListOptions: gitlab.ListOptions{
 			PerPage: 100,
@@ -58,6 +68,7 @@ func createProjects(ctx context.Context, client *gitlab.Client, group string) []
 			return nil
 		}
 
+		resources := []terraformutils.Resource{}
 		for _, project := range projects {
 			resource := terraformutils.NewSimpleResource(
 				strconv.FormatInt(int64(project.ID), 10),
@@ -77,13 +88,16 @@ func createProjects(ctx context.Context, client *gitlab.Client, group string) []
 			resources = append(resources, createTagProtections(ctx, client, project)...)
 			resources = append(resources, createProjectMembership(ctx, client, project)...)
 		}
+		if err := batch(resources); err != nil {
+			return err
+		}
 
 		if resp.NextPage == 0 {
 			break
 		}
 		opt.Page = resp.NextPage
 	}
-	return resources
+	return nil
 }
 func createProjectVariables(ctx context.Context, client *gitlab.Client, project *gitlab.Project) []terraformutils.Resource {
 	resources := []terraformutils.Resource{}
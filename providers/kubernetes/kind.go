@@ -68,10 +68,6 @@ func (k *Kind) InitResources() error {
 
 	for i := 0; i < items.Len(); i++ {
 		item := items.Index(i)
-		// Filter to resources that aren't owned by any other resource
-		if item.FieldByName("OwnerReferences").Len() > 0 {
-			continue
-		}
 
 		name := ""
 		if k.Namespaced {
@@ -80,13 +76,19 @@ func (k *Kind) InitResources() error {
 			name = item.FieldByName("Name").String()
 		}
 
-		k.Resources = append(k.Resources, terraformutils.NewSimpleResource(
+		resource := terraformutils.NewSimpleResource(
 			name,
 			name,
 			extractTfResourceName(k.Name),
 			"kubernetes",
 			[]string{},
-		))
+		)
+		// resources owned by other resources are created by their controllers
+		if owners := item.FieldByName("OwnerReferences"); owners.Len() > 0 {
+			owner := owners.Index(0).Interface().(metav1.OwnerReference)
+			resource.ManagedBy = owner.Kind + " " + owner.Name
+		}
+		k.Resources = append(k.Resources, resource)
 	}
 	return nil
 }
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 
 	restclient "k8s.io/client-go/rest"
@@ -61,43 +62,67 @@ func (p *KubernetesProvider) GetName() string {
 }
 
 func (p *KubernetesProvider) InitService(serviceName string, verbose bool) error {
-	var isSupported bool
-	if _, isSupported = p.GetSupportedService()[serviceName]; !isSupported {
+	service, isSupported := p.GetSupportedService()[serviceName]
+	if !isSupported {
 		return errors.New("kubernetes: " + serviceName + " not supported resource")
 	}
-	p.Service = p.GetSupportedService()[serviceName]
+	p.Service = service
 	p.Service.SetName(serviceName)
 	p.Service.SetVerbose(verbose)
 	p.Service.SetProviderName(p.GetName())
 	return nil
 }
 
+// discoveredKinds caches the kinds supported by the cluster and the provider plugin,
+// discovery and the plugin schema are the same for all services of a command
+var (
+	discoveredKindsMu sync.Mutex
+	discoveredKinds   map[string]Kind
+)
+
 // GetSupportService return map of supported resource for Kubernetes
 func (p *KubernetesProvider) GetSupportedService() map[string]terraformutils.ServiceGenerator {
 	resources := make(map[string]terraformutils.ServiceGenerator)
+	kinds, err := p.discoverKinds()
+	if err != nil {
+		log.Println(err)
+		return resources
+	}
+	for name, kind := range kinds {
+		kind := kind
+		resources[name] = &kind
+	}
+	return resources
+}
+
+func (p *KubernetesProvider) discoverKinds() (map[string]Kind, error) {
+	discoveredKindsMu.Lock()
+	defer discoveredKindsMu.Unlock()
+	if discoveredKinds != nil {
+		return discoveredKinds, nil
+	}
 
 	config, _, err := initClientAndConfig()
 	if err != nil {
-		return resources
+		return nil, err
 	}
 
 	dc, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
-		log.Println(err)
-		return resources
+		return nil, err
 	}
 
 	lists, err := dc.ServerPreferredResources()
 	if err != nil {
-		log.Println(err)
-		return resources
+		return nil, err
 	}
 	provider, err := providerwrapper.NewProviderWrapper("kubernetes", cty.Value{}, p.verbose == "true")
 	if err != nil {
-		log.Println(err)
-		return resources
+		return nil, err
 	}
+	defer provider.Kill()
 	resp := provider.GetSchema()
+	kinds := map[string]Kind{}
 	for _, list := range lists {
 		if len(list.APIResources) == 0 {
 			continue
@@ -123,7 +148,7 @@ func (p *KubernetesProvider) GetSupportedService() map[string]terraformutils.Ser
 				continue
 			}
 
-			resources[resource.Name] = &Kind{
+			kinds[resource.Name] = Kind{
 				Group:      gv.Group,
 				Version:    gv.Version,
 				Name:       resource.Kind,
@@ -131,7 +156,8 @@ func (p *KubernetesProvider) GetSupportedService() map[string]terraformutils.Ser
 			}
 		}
 	}
-	return resources
+	discoveredKinds = kinds
+	return kinds, nil
 }
 
 // InitClientAndConfig uses the KUBECONFIG environment variable to create
@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder"
 )
 
 type RBTService struct { //nolint
@@ -27,7 +28,7 @@ type RBTService struct { //nolint
 
 func (s *RBTService) generateRequest(uri string) ([]byte, error) {
 	tr := &http.Transport{}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: recorder.Transport(tr)}
 	req, err := http.NewRequest("GET", s.Args["endpoint"].(string)+uri, nil)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,38 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmq_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/cmd"
+	"github.com/GoogleCloudPlatform/terraformer/providers/rabbitmq"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder/recordertest"
+)
+
+// The cassette is a hand-written fixture, TERRAFORMER_RECORD=1 records it with RABBITMQ_SERVER_URL, RABBITMQ_USERNAME, RABBITMQ_PASSWORD
+func TestReplayVhostsAndUsers(t *testing.T) {
+	endpoint := "http://localhost:15672"
+	if os.Getenv(recordertest.RecordEnv) != "" {
+		endpoint = os.Getenv("RABBITMQ_SERVER_URL")
+	}
+	recordertest.Run(t, "testdata/replay/cassette.json", "testdata/replay/golden", func(output string) error {
+		options := cmd.NewImportOptions()
+		options.Resources = []string{"users", "vhosts"}
+		options.PathOutput = output
+		return cmd.Import(&rabbitmq.RBTProvider{}, options, []string{endpoint, os.Getenv("RABBITMQ_USERNAME"), os.Getenv("RABBITMQ_PASSWORD")})
+	})
+}
@@ -0,0 +1,7 @@
+output "rabbitmq_user_tfer--user_guest_id" {
+  value = "${rabbitmq_user.tfer--user_guest.id}"
+}
+
+output "rabbitmq_user_tfer--user_orders_app_id" {
+  value = "${rabbitmq_user.tfer--user_orders_app.id}"
+}
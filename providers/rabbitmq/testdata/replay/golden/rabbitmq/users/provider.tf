@@ -0,0 +1,8 @@
+terraform {
+  required_providers {
+    rabbitmq = {
+      source  = "cyrilgdn/rabbitmq"
+      version = "~> 1.5.1"
+    }
+  }
+}
@@ -0,0 +1,9 @@
+resource "rabbitmq_user" "tfer--user_guest" {
+  name = "guest"
+  tags = ["administrator"]
+}
+
+resource "rabbitmq_user" "tfer--user_orders_app" {
+  name = "orders-app"
+  tags = ["management"]
+}
@@ -0,0 +1,7 @@
+output "rabbitmq_vhost_tfer--vhost_orders_id" {
+  value = "${rabbitmq_vhost.tfer--vhost_orders.id}"
+}
+
+output "rabbitmq_vhost_tfer--vhost_slash_id" {
+  value = "${rabbitmq_vhost.tfer--vhost_slash.id}"
+}
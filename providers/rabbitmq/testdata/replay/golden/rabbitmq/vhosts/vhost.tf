@@ -0,0 +1,7 @@
+resource "rabbitmq_vhost" "tfer--vhost_orders" {
+  name = "orders"
+}
+
+resource "rabbitmq_vhost" "tfer--vhost_slash" {
+  name = "/"
+}
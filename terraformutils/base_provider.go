@@ -30,6 +30,13 @@ type ProviderGenerator interface {
 	GetProviderData(arg ...string) map[string]interface{}
 	GenerateOutputPath() error
 	GetResourceConnections() map[string]map[string][]string
+	GetPathAttributes() map[string]string
+}
+
+// PathAttributesProvider is implemented by providers which look up path attributes remotely,
+// only the attributes of placeholders in the path pattern are resolved
+type PathAttributesProvider interface {
+	GetPathAttributesFor(names []string) map[string]string
 }
 
 type Provider struct {
@@ -72,3 +79,8 @@ func (p *Provider) GetSupportedService() map[string]ServiceGenerator {
 func (p *Provider) GetBasicConfig() cty.Value {
 	return cty.ObjectVal(map[string]cty.Value{})
 }
+
+// GetPathAttributes returns values for provider level path pattern placeholders like {region} or {project}
+func (p *Provider) GetPathAttributes() map[string]string {
+	return map[string]string{}
+}
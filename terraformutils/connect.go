@@ -14,10 +14,48 @@
 
 package terraformutils
 
+import "sort"
+
+// ReferenceFormatter renders the interpolation used to link an attribute to the
+// output key of a resource imported by another service.
+type ReferenceFormatter func(service string, resource Resource, key string) string
+
+// RemoteStateReference links resources through terraform_remote_state data sources.
+// Data sources are referenced directly, ConnectServicesWithReference copies them into
+// the directories referencing them.
+func RemoteStateReference(service string, resource Resource, key string) string {
+	if resource.DataSource {
+		return "${" + resource.Address() + "." + key + "}"
+	}
+	return "${data.terraform_remote_state." + service + ".outputs." + OutputName(resource, key) + "}"
+}
+
+// OutputName is the name of the output exposing key of resource.
+func OutputName(resource Resource, key string) string {
+	return resource.InstanceInfo.Type + "_" + resource.ResourceName + "_" + key
+}
+
 func ConnectServices(importResources map[string][]Resource, isServicePath bool, resourceConnections map[string]map[string][]string) map[string][]Resource {
-	for resource, connection := range resourceConnections {
+	return ConnectServicesWithReference(importResources, isServicePath, resourceConnections, RemoteStateReference)
+}
+
+func ConnectServicesWithReference(importResources map[string][]Resource, isServicePath bool, resourceConnections map[string]map[string][]string, reference ReferenceFormatter) map[string][]Resource {
+	// a value is replaced by the reference found first, services are connected in order
+	var services []string
+	for resource := range resourceConnections {
+		services = append(services, resource)
+	}
+	sort.Strings(services)
+	for _, resource := range services {
+		connection := resourceConnections[resource]
 		if _, exist := importResources[resource]; exist {
-			for k, connectionPairs := range connection {
+			var connected []string
+			for k := range connection {
+				connected = append(connected, k)
+			}
+			sort.Strings(connected)
+			for _, k := range connected {
+				connectionPairs := connection[k]
 				if len(connectionPairs)%2 == 1 {
 					continue
 				}
@@ -26,9 +64,9 @@ func ConnectServices(importResources map[string][]Resource, isServicePath bool,
 						connectionPair := []string{connectionPairs[i*2], connectionPairs[i*2+1]}
 						for _, ccc := range cc {
 							if !isServicePath {
-								mapResource(importResources, resource, connectionPair, ccc, "local")
-							} else {
-								mapResource(importResources, resource, connectionPair, ccc, k)
+								mapResource(importResources, resource, connectionPair, ccc, "local", reference)
+							} else if mapResource(importResources, resource, connectionPair, ccc, k, reference) && ccc.DataSource && k != resource {
+								copyDataSource(importResources, resource, ccc)
 							}
 						}
 					}
@@ -39,19 +77,38 @@ func ConnectServices(importResources map[string][]Resource, isServicePath bool,
 	return importResources
 }
 
-func mapResource(importResources map[string][]Resource, resource string, connectionPair []string, resourceToMap Resource, k string) {
+// mapResource replaces values of resource with references to resourceToMap, it returns whether
+// a value was replaced
+func mapResource(importResources map[string][]Resource, resource string, connectionPair []string, resourceToMap Resource, k string, reference ReferenceFormatter) bool {
+	mapped := false
 	for i := range importResources[resource] {
 		key := connectionPair[1]
 		if connectionPair[1] == "self_link" || connectionPair[1] == "id" {
 			key = resourceToMap.GetIDKey()
 		}
 		mappingResourceAttr := WalkAndGet(key, resourceToMap.InstanceState.Attributes)
-		keyValue := resourceToMap.InstanceInfo.Type + "_" + resourceToMap.ResourceName + "_" + key
-		linkValue := "${data.terraform_remote_state." + k + ".outputs." + keyValue + "}"
+		linkValue := reference(k, resourceToMap, key)
 
 		if len(mappingResourceAttr) == 1 {
 			resourceIdentifier := mappingResourceAttr[0].(string)
 			WalkAndOverride(connectionPair[0], resourceIdentifier, linkValue, importResources[resource][i].Item)
+			for _, value := range WalkAndGet(connectionPair[0], importResources[resource][i].Item) {
+				if value == linkValue {
+					mapped = true
+				}
+			}
+		}
+	}
+	return mapped
+}
+
+// copyDataSource adds the data source to the resources of service, once, so references to it
+// resolve in the directory of service
+func copyDataSource(importResources map[string][]Resource, service string, dataSource Resource) {
+	for _, r := range importResources[service] {
+		if r.DataSource && r.Address() == dataSource.Address() {
+			return
 		}
 	}
+	importResources[service] = append(importResources[service], dataSource)
 }
@@ -46,6 +46,39 @@ func TestSimpleReference(t *testing.T) {
 	}
 }
 
+func TestDataSourceReference(t *testing.T) {
+	dataSource := prepareNoAttrs("ID2", "type2")
+	dataSource.DataSource = true
+	importResources := map[string][]Resource{
+		"type1": {prepare("ID1", "type1", map[string]string{
+			"type2_ref": "ID2",
+		}, map[string]interface{}{
+			"type2_ref": "ID2",
+		}), prepare("ID3", "type1", map[string]string{
+			"type2_ref": "ID2",
+		}, map[string]interface{}{
+			"type2_ref": "ID2",
+		})},
+		"type2": {dataSource},
+	}
+
+	resourceConnections := map[string]map[string][]string{
+		"type1": {
+			"type2": {"type2_ref", "id"},
+		},
+	}
+	resources := ConnectServices(importResources, true, resourceConnections)
+
+	if !reflect.DeepEqual(resources["type1"][0].Item, map[string]interface{}{
+		"type2_ref": "${data.type2.tfer--name-type2.id}",
+	}) {
+		t.Errorf("failed to connect %v", resources["type1"][0].Item)
+	}
+	if len(resources["type1"]) != 3 || resources["type1"][2].Address() != dataSource.Address() {
+		t.Errorf("the data source should be copied once into the referencing directory, got %v", resources["type1"])
+	}
+}
+
 func TestManyReferences(t *testing.T) {
 	importResources := map[string][]Resource{
 		"type1": {prepare("ID1", "type1", map[string]string{
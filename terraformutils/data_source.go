@@ -0,0 +1,70 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+)
+
+// dataSourceLookupArguments identify a resource when a data source has no required arguments
+var dataSourceLookupArguments = []string{"id", "name", "arn"}
+
+// Address of the resource in configuration, data sources have the data. prefix
+func (r Resource) Address() string {
+	if r.DataSource {
+		return "data." + r.InstanceInfo.Type + "." + r.ResourceName
+	}
+	return r.InstanceInfo.Type + "." + r.ResourceName
+}
+
+// AsDataSource turns the resource into a data source which looks it up by the required
+// arguments of the data source schema, or else by its id, name or arn.
+func (r *Resource) AsDataSource(schema *configschema.Block) error {
+	item := map[string]interface{}{}
+	for name, attribute := range schema.Attributes {
+		if !attribute.Required {
+			continue
+		}
+		value := r.InstanceState.Attributes[name]
+		if value == "" {
+			return fmt.Errorf("no value for required argument %s of data source %s", name, r.InstanceInfo.Type)
+		}
+		item[name] = value
+	}
+	if len(item) == 0 {
+		for _, name := range dataSourceLookupArguments {
+			attribute, exist := schema.Attributes[name]
+			if !exist || !attribute.Optional {
+				continue
+			}
+			value := r.InstanceState.Attributes[name]
+			if name == "id" {
+				value = r.InstanceState.ID
+			}
+			if value != "" {
+				item[name] = value
+				break
+			}
+		}
+	}
+	if len(item) == 0 {
+		return fmt.Errorf("data source %s has no id, name or arn argument", r.InstanceInfo.Type)
+	}
+	r.Item = item
+	r.DataSource = true
+	return nil
+}
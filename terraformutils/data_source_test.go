@@ -0,0 +1,61 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAsDataSource(t *testing.T) {
+	vpc := prepare("vpc-1", "aws_vpc", map[string]string{"cidr_block": "10.0.0.0/16"}, map[string]interface{}{"cidr_block": "10.0.0.0/16"})
+	err := vpc.AsDataSource(&configschema.Block{Attributes: map[string]*configschema.Attribute{
+		"id":         {Type: cty.String, Optional: true, Computed: true},
+		"cidr_block": {Type: cty.String, Optional: true, Computed: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vpc.DataSource || !reflect.DeepEqual(vpc.Item, map[string]interface{}{"id": "vpc-1"}) {
+		t.Errorf("unexpected data source %v", vpc.Item)
+	}
+
+	key := prepare("key-1", "aws_kms_key", map[string]string{"key_id": "key-1"}, map[string]interface{}{})
+	err = key.AsDataSource(&configschema.Block{Attributes: map[string]*configschema.Attribute{
+		"key_id": {Type: cty.String, Required: true},
+		"arn":    {Type: cty.String, Computed: true},
+	}})
+	if err != nil || !reflect.DeepEqual(key.Item, map[string]interface{}{"key_id": "key-1"}) {
+		t.Errorf("unexpected data source %v, %v", key.Item, err)
+	}
+
+	data, err := HclPrintResource([]Resource{vpc, key}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `data "aws_vpc" "tfer--name-aws_vpc" {`) {
+		t.Errorf("no data block in %s", data)
+	}
+	if len(NewTfState([]Resource{vpc, key}).Modules[0].Resources) != 0 {
+		t.Error("data sources must not be written to state")
+	}
+	if reference := RemoteStateReference("local", vpc, "id"); reference != "${data.aws_vpc.tfer--name-aws_vpc.id}" {
+		t.Errorf("unexpected reference %s", reference)
+	}
+}
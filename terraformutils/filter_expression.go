@@ -0,0 +1,249 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operators of filter expressions, longer operators first so that <= wins over <.
+// A single = is an alias of == for field paths, e.g. tags.env=prod.
+var filterOperators = []string{"==", "!=", "=~", "!~", "<=", ">=", "<", ">", "="}
+
+const filterOrSeparator = "||"
+
+// filterTypeRe matches the type prefix of a clause. Attribute names can't contain :,
+// so a : after a dot belongs to a map key like tags.aws:cloudformation:stack-name.
+var filterTypeRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// FilterClause compares the values found at FieldPath of a resource with Value.
+// == and != match globs with * and ?, =~ and !~ match regular expressions.
+type FilterClause struct {
+	ServiceName string
+	FieldPath   string
+	Operator    string
+	Value       string
+	re          *regexp.Regexp
+}
+
+// FilterExpression matches resources matching any of its clauses
+type FilterExpression struct {
+	Clauses []FilterClause
+}
+
+// IsFilterExpression tells expressions apart from service=id1:id2 and Type=x;Name=y;Value=z filters.
+// path=value is an expression when path is a dotted field path, like tags.env=prod.
+func IsFilterExpression(rawFilter string) bool {
+	if strings.Contains(rawFilter, filterOrSeparator) {
+		return true
+	}
+	for _, operator := range filterOperators {
+		if len(operator) == 2 && strings.Contains(rawFilter, operator) {
+			return true
+		}
+	}
+	if !strings.Contains(rawFilter, "=") {
+		return strings.ContainsAny(rawFilter, "<>")
+	}
+	if strings.Contains(rawFilter, ";") || strings.HasPrefix(rawFilter, "Name=") {
+		return false
+	}
+	return strings.Contains(strings.SplitN(rawFilter, "=", 2)[0], ".")
+}
+
+// ParseFilterExpression parses clauses like [type:]path<operator>value separated by ||
+func ParseFilterExpression(rawFilter string) (*FilterExpression, error) {
+	expression := &FilterExpression{}
+	for _, rawClause := range strings.Split(rawFilter, filterOrSeparator) {
+		clause, err := parseFilterClause(strings.TrimSpace(rawClause))
+		if err != nil {
+			return nil, err
+		}
+		expression.Clauses = append(expression.Clauses, clause)
+	}
+	return expression, nil
+}
+
+func parseFilterClause(rawClause string) (FilterClause, error) {
+	index, operator := -1, ""
+	for _, candidate := range filterOperators {
+		i := strings.Index(rawClause, candidate)
+		if i >= 0 && (index == -1 || i < index) {
+			index, operator = i, candidate
+		}
+	}
+	if index <= 0 {
+		return FilterClause{}, fmt.Errorf("no operator in filter %q", rawClause)
+	}
+	clause := FilterClause{
+		FieldPath: strings.TrimSpace(rawClause[:index]),
+		Operator:  operator,
+		Value:     strings.TrimSpace(rawClause[index+len(operator):]),
+	}
+	if operator == "=" {
+		clause.Operator = "=="
+	}
+	if i := strings.Index(clause.FieldPath, ":"); i >= 0 && filterTypeRe.MatchString(clause.FieldPath[:i]) {
+		clause.ServiceName, clause.FieldPath = clause.FieldPath[:i], clause.FieldPath[i+1:]
+	}
+	if clause.FieldPath == "" {
+		return FilterClause{}, fmt.Errorf("no field in filter %q", rawClause)
+	}
+	var err error
+	switch clause.Operator {
+	case "=~", "!~":
+		clause.re, err = regexp.Compile(clause.Value)
+	case "==", "!=":
+		if strings.ContainsAny(clause.Value, "*?") {
+			glob := regexp.QuoteMeta(clause.Value)
+			glob = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(glob)
+			clause.re, err = regexp.Compile("^" + glob + "$")
+		}
+	default:
+		_, err = strconv.ParseFloat(clause.Value, 64)
+	}
+	if err != nil {
+		return FilterClause{}, fmt.Errorf("invalid value in filter %q: %v", rawClause, err)
+	}
+	return clause, nil
+}
+
+func (c FilterClause) isApplicable(resource Resource) bool {
+	return c.ServiceName == "" || c.ServiceName == resource.InstanceInfo.Type ||
+		c.ServiceName == strings.TrimPrefix(resource.InstanceInfo.Type, resource.Provider+"_")
+}
+
+func (c FilterClause) match(resource Resource) bool {
+	var values []interface{}
+	if c.FieldPath == "id" {
+		values = []interface{}{resource.InstanceState.ID}
+	} else {
+		values = WalkAndGet(c.FieldPath, resource.InstanceState.Attributes)
+		if len(values) == 0 {
+			values = WalkAndGet(c.FieldPath, resource.Item)
+		}
+	}
+	negated := c.Operator == "!=" || c.Operator == "!~"
+	for _, value := range values {
+		if c.matchValue(fmt.Sprint(value)) {
+			return !negated
+		}
+	}
+	return negated
+}
+
+func (c FilterClause) matchValue(value string) bool {
+	switch c.Operator {
+	case "==", "!=":
+		if c.re != nil {
+			return c.re.MatchString(value)
+		}
+		return value == c.Value
+	case "=~", "!~":
+		return c.re.MatchString(value)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	limit, _ := strconv.ParseFloat(c.Value, 64)
+	switch c.Operator {
+	case "<":
+		return number < limit
+	case "<=":
+		return number <= limit
+	case ">":
+		return number > limit
+	default:
+		return number >= limit
+	}
+}
+
+// Match is true when an applicable clause matches or when no clause applies to the resource
+func (e *FilterExpression) Match(resource Resource) bool {
+	applicable := false
+	for _, clause := range e.Clauses {
+		if !clause.isApplicable(resource) {
+			continue
+		}
+		applicable = true
+		if clause.match(resource) {
+			return true
+		}
+	}
+	return !applicable
+}
+
+// isInitial expressions only look at IDs, so they filter before refresh
+func (e *FilterExpression) isInitial() bool {
+	for _, clause := range e.Clauses {
+		if clause.FieldPath != "id" {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpandFilterFiles replaces @path filters by the filters of the file, one per line.
+// Empty lines and lines starting with # are skipped.
+func ExpandFilterFiles(rawFilters []string) ([]string, error) {
+	var filters []string
+	for _, rawFilter := range rawFilters {
+		if !strings.HasPrefix(rawFilter, "@") {
+			filters = append(filters, rawFilter)
+			continue
+		}
+		fileFilters, err := readFilterFile(strings.TrimPrefix(rawFilter, "@"))
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, fileFilters...)
+	}
+	for _, rawFilter := range filters {
+		if IsFilterExpression(rawFilter) {
+			if _, err := ParseFilterExpression(rawFilter); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return filters, nil
+}
+
+func readFilterFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var filters []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		filters = append(filters, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("failed to read filters from " + path + ": " + err.Error())
+	}
+	return filters, nil
+}
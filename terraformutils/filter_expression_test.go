@@ -0,0 +1,105 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func filterResource(id, resourceType string, attributes map[string]string) Resource {
+	return NewResource(id, id, resourceType, "aws", attributes, []string{}, map[string]interface{}{})
+}
+
+func filteredIDs(filters []string, isInitial bool) []string {
+	service := Service{Resources: []Resource{
+		filterResource("logs-eu", "aws_s3_bucket", map[string]string{"tags.owner": "platform"}),
+		filterResource("assets", "aws_s3_bucket", map[string]string{"tags.owner": "web"}),
+		filterResource("vpc-1", "aws_vpc", map[string]string{"tags.owner": "platform", "tags.env": "prod"}),
+		filterResource("vol-1", "aws_ebs_volume", map[string]string{"size": "500"}),
+		filterResource("vol-2", "aws_ebs_volume", map[string]string{"size": "8"}),
+		filterResource("stack-vpc", "aws_vpc", map[string]string{"tags.aws:cloudformation:stack-name": "network"}),
+	}}
+	service.ParseFilters(filters)
+	FilterCleanup(&service, isInitial)
+	var ids []string
+	for _, r := range service.Resources {
+		ids = append(ids, r.InstanceState.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestFilterExpressions(t *testing.T) {
+	cases := []struct {
+		filters   []string
+		isInitial bool
+		expected  []string
+	}{
+		{[]string{"s3_bucket:id!~^logs-"}, true, []string{"assets", "stack-vpc", "vol-1", "vol-2", "vpc-1"}},
+		{[]string{"tags.owner==platform"}, false, []string{"logs-eu", "vpc-1"}},
+		{[]string{"aws_s3_bucket:id==log*"}, true, []string{"logs-eu", "stack-vpc", "vol-1", "vol-2", "vpc-1"}},
+		{[]string{"ebs_volume:size>=100"}, false, []string{"assets", "logs-eu", "stack-vpc", "vol-1", "vpc-1"}},
+		{[]string{"tags.env==prod || tags.owner==web"}, false, []string{"assets", "vpc-1"}},
+		{[]string{"tags.owner!=platform", "s3_bucket:id=~s"}, false, []string{"assets", "stack-vpc", "vol-1", "vol-2"}},
+		{[]string{"tags.env=prod"}, false, []string{"vpc-1"}},
+		{[]string{"tags.aws:cloudformation:stack-name==network"}, false, []string{"stack-vpc"}},
+		{[]string{"vpc:tags.aws:cloudformation:stack-name=network"}, false, []string{"assets", "logs-eu", "stack-vpc", "vol-1", "vol-2"}},
+	}
+	for _, c := range cases {
+		if ids := filteredIDs(c.filters, c.isInitial); !reflect.DeepEqual(ids, c.expected) {
+			t.Errorf("filters %v: expected %v, got %v", c.filters, c.expected, ids)
+		}
+	}
+}
+
+func TestFilterExpressionParsing(t *testing.T) {
+	if IsFilterExpression("aws_vpc=myid") || IsFilterExpression("Type=sg;Name=vpc_id;Value=VPC_ID") {
+		t.Error("legacy filters must not be parsed as expressions")
+	}
+	if !IsFilterExpression("tags.env=prod") {
+		t.Error("path=value filters must be parsed as expressions")
+	}
+	if _, err := ParseFilterExpression("id=~("); err == nil {
+		t.Error("invalid regular expressions must fail")
+	}
+	if _, err := ParseFilterExpression("size>big"); err == nil {
+		t.Error("numeric comparisons must need numbers")
+	}
+}
+
+func TestExpandFilterFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "filters.txt")
+	content := "# skip logs buckets\ns3_bucket:id!~^logs-\n\ntags.owner==platform\n"
+	if err := ioutil.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := ExpandFilterFiles([]string{"vpc=vpc-1", "@" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(filters, []string{"vpc=vpc-1", "s3_bucket:id!~^logs-", "tags.owner==platform"}) {
+		t.Errorf("unexpected filters %v", filters)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var resourceReferenceRe = regexp.MustCompile(`\$\{([\w-]+)\.([\w-]+)\.([\w.-]+)}`)
+
+type GraphNode struct {
+	Address   string `json:"address"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	ID        string `json:"id"`
+	Service   string `json:"service"`
+	Directory string `json:"directory"`
+}
+
+// GraphEdge links the attribute of a resource to the key of the resource it references
+type GraphEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Attribute string `json:"attribute"`
+	Key       string `json:"key"`
+}
+
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// NewGraph builds the dependency graph of imported resources from the resource connections
+// of the provider and from references providers already put into Items.
+// It has to be built before ConnectServices replaces the connected values.
+func NewGraph(importResources map[string][]Resource, resourceConnections map[string]map[string][]string, directory func(service string, resource Resource) string) *Graph {
+	g := &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	nodes := map[string]bool{}
+	for service, resources := range importResources {
+		for _, r := range resources {
+			address := r.Address()
+			if nodes[address] {
+				continue
+			}
+			nodes[address] = true
+			g.Nodes = append(g.Nodes, GraphNode{
+				Address:   address,
+				Type:      r.InstanceInfo.Type,
+				Name:      r.ResourceName,
+				ID:        r.InstanceState.ID,
+				Service:   service,
+				Directory: directory(service, r),
+			})
+		}
+	}
+
+	edges := map[GraphEdge]bool{}
+	addEdge := func(edge GraphEdge) {
+		if edge.From != edge.To && nodes[edge.To] && !edges[edge] {
+			edges[edge] = true
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+	for service, connections := range resourceConnections {
+		for connectedService, connectionPairs := range connections {
+			if len(connectionPairs)%2 == 1 {
+				continue
+			}
+			for i := 0; i < len(connectionPairs)/2; i++ {
+				attribute := connectionPairs[i*2]
+				for _, connected := range importResources[connectedService] {
+					key := connectionPairs[i*2+1]
+					if key == "self_link" || key == "id" {
+						key = connected.GetIDKey()
+					}
+					values := WalkAndGet(key, connected.InstanceState.Attributes)
+					if len(values) != 1 {
+						continue
+					}
+					for _, r := range importResources[service] {
+						for _, value := range WalkAndGet(attribute, r.Item) {
+							if value == values[0] {
+								addEdge(GraphEdge{
+									From:      r.Address(),
+									To:        connected.Address(),
+									Attribute: attribute,
+									Key:       key,
+								})
+								break
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, resources := range importResources {
+		for _, r := range resources {
+			from := r.Address()
+			walkReferences(r.Item, "", func(attribute string, match []string) {
+				addEdge(GraphEdge{From: from, To: match[1] + "." + match[2], Attribute: attribute, Key: match[3]})
+			})
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Address < g.Nodes[j].Address })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		if g.Edges[i].To != g.Edges[j].To {
+			return g.Edges[i].To < g.Edges[j].To
+		}
+		return g.Edges[i].Attribute < g.Edges[j].Attribute
+	})
+	return g
+}
+
+func walkReferences(v interface{}, path string, found func(attribute string, match []string)) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			if path != "" {
+				k = path + "." + k
+			}
+			walkReferences(item, k, found)
+		}
+	case []interface{}:
+		for _, item := range value {
+			walkReferences(item, path, found)
+		}
+	case string:
+		for _, match := range resourceReferenceRe.FindAllStringSubmatch(value, -1) {
+			found(path, match)
+		}
+	}
+}
+
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// DOT renders the graph for Graphviz, resources of one output directory are clustered
+func (g *Graph) DOT() []byte {
+	var b bytes.Buffer
+	b.WriteString("digraph terraformer {\n")
+	b.WriteString("  rankdir = \"LR\";\n")
+	var directories []string
+	nodesByDirectory := map[string][]GraphNode{}
+	for _, node := range g.Nodes {
+		if _, exist := nodesByDirectory[node.Directory]; !exist {
+			directories = append(directories, node.Directory)
+		}
+		nodesByDirectory[node.Directory] = append(nodesByDirectory[node.Directory], node)
+	}
+	sort.Strings(directories)
+	for i, directory := range directories {
+		fmt.Fprintf(&b, "  subgraph \"cluster_%d\" {\n", i)
+		fmt.Fprintf(&b, "    label = %s;\n", strconv.Quote(directory))
+		for _, node := range nodesByDirectory[directory] {
+			fmt.Fprintf(&b, "    %s [label=%s, tooltip=%s];\n",
+				strconv.Quote(node.Address),
+				strconv.Quote(node.Address+"\n"+node.ID),
+				strconv.Quote(node.Service))
+		}
+		b.WriteString("  }\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), strconv.Quote(edge.Attribute))
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewGraph(t *testing.T) {
+	importResources := map[string][]Resource{
+		"type1": {prepare("ID1", "type1", map[string]string{
+			"type2_ref": "ID2",
+		}, map[string]interface{}{
+			"type2_ref": "ID2",
+			"nested":    []interface{}{map[string]interface{}{"type3_ref": "${type3.tfer--name-type3.arn}"}},
+		})},
+		"type2": {prepareNoAttrs("ID2", "type2"), prepareNoAttrs("ID4", "type4")},
+		"type3": {prepareNoAttrs("ID3", "type3")},
+	}
+	resourceConnections := map[string]map[string][]string{
+		"type1": {
+			"type2": {"type2_ref", "id"},
+		},
+	}
+	graph := NewGraph(importResources, resourceConnections, func(service string, r Resource) string {
+		return "generated/" + service + "/"
+	})
+
+	if len(graph.Nodes) != 4 || graph.Nodes[0].Address != "type1.tfer--name-type1" || graph.Nodes[0].Directory != "generated/type1/" {
+		t.Errorf("unexpected nodes %v", graph.Nodes)
+	}
+	expected := []GraphEdge{
+		{From: "type1.tfer--name-type1", To: "type2.tfer--name-type2", Attribute: "type2_ref", Key: "id"},
+		{From: "type1.tfer--name-type1", To: "type3.tfer--name-type3", Attribute: "nested.type3_ref", Key: "arn"},
+	}
+	if !reflect.DeepEqual(graph.Edges, expected) {
+		t.Errorf("unexpected edges %v", graph.Edges)
+	}
+	dot := string(graph.DOT())
+	if !strings.Contains(dot, `"type1.tfer--name-type1" -> "type2.tfer--name-type2" [label="type2_ref"];`) {
+		t.Errorf("unexpected dot %s", dot)
+	}
+}
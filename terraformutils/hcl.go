@@ -157,7 +157,9 @@ func Print(data interface{}, mapsObjects map[string]struct{}, format string) ([]
 	case "hcl":
 		return hclPrint(data, mapsObjects)
 	case "json":
-		return jsonPrint(data)
+		// JSON configuration reads expressions from strings
+		data, err := jsonPrint(data)
+		return bytes.ReplaceAll(data, []byte(expressionPrefix), nil), err
 	}
 	return []byte{}, errors.New("error: unknown output format")
 }
@@ -188,6 +190,7 @@ func hclPrint(data interface{}, mapsObjects map[string]struct{}) ([]byte, error)
 
 	// ...but leave whitespace between resources
 	s = strings.ReplaceAll(s, "}\nresource", "}\n\nresource")
+	s = strings.ReplaceAll(s, "}\ndata", "}\n\ndata")
 
 	// Apply Terraform style (alignment etc.)
 	formatted, err := hclPrinter.Format([]byte(s))
@@ -198,6 +201,7 @@ func hclPrint(data interface{}, mapsObjects map[string]struct{}) ([]byte, error)
 	formatted = terraform12Adjustments(formatted, mapsObjects)
 	// hack for support terraform 0.13
 	formatted = terraform13Adjustments(formatted)
+	formatted = expressionAdjustments(formatted)
 	if err != nil {
 		log.Println("Invalid HCL follows:")
 		for i, line := range strings.Split(s, "\n") {
@@ -237,23 +241,45 @@ func terraform12Adjustments(formatted []byte, mapsObjects map[string]struct{}) [
 	return []byte(s)
 }
 
+// terraform13Adjustments turns required_providers "name" { ... } blocks into
+// required_providers { name = { ... } } attributes
 func terraform13Adjustments(formatted []byte) []byte {
-	s := string(formatted)
-	requiredProvidersRe := regexp.MustCompile("required_providers \".*\" {")
-	oldRequiredProviders := "\"required_providers\""
-	newRequiredProviders := "required_providers"
-	lines := strings.Split(s, "\n")
-	for i, line := range lines {
-		if requiredProvidersRe.MatchString(line) {
-			parts := strings.Split(strings.TrimSpace(line), " ")
-			provider := strings.ReplaceAll(parts[1], "\"", "")
-			lines[i] = "\t" + newRequiredProviders + " {"
-			lines[i+1] = "\t\t" + provider + " = {\n\t" + lines[i+1] + "\n\t\t}"
+	requiredProvidersRe := regexp.MustCompile(`^(\s*)required_providers "(.*)" {$`)
+	var lines []string
+	indent, inRequiredProviders := "", false
+	for _, line := range strings.Split(string(formatted), "\n") {
+		if match := requiredProvidersRe.FindStringSubmatch(line); match != nil {
+			indent, inRequiredProviders = match[1], true
+			lines = append(lines, indent+"required_providers {", indent+"  "+match[2]+" = {")
+			continue
 		}
-		lines[i] = strings.Replace(lines[i], oldRequiredProviders, newRequiredProviders, 1)
+		if inRequiredProviders && line == indent+"}" {
+			inRequiredProviders = false
+			lines = append(lines, indent+"  }", line)
+			continue
+		}
+		if inRequiredProviders && line != "" {
+			line = "  " + line
+		}
+		lines = append(lines, line)
 	}
-	s = strings.Join(lines, "\n")
-	return []byte(s)
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// expressionPrefix marks strings of Items which are printed as expressions, like the attribute
+// references of ignore_changes, values read from the cloud are always quoted
+const expressionPrefix = "__terraformer_expression__:"
+
+var expressionRe = regexp.MustCompile(`"` + expressionPrefix + `([^"]*)"`)
+
+// Expression returns a value of Items printed unquoted in HCL, e.g. an attribute reference
+func Expression(expression string) string {
+	return expressionPrefix + expression
+}
+
+// expressionAdjustments unquotes the values marked by Expression
+func expressionAdjustments(formatted []byte) []byte {
+	return expressionRe.ReplaceAll(formatted, []byte("$1"))
 }
 
 func escapeRune(s string) string {
@@ -270,35 +296,57 @@ func TfSanitize(name string) string {
 // Print hcl file from TerraformResource + provider
 func HclPrintResource(resources []Resource, providerData map[string]interface{}, output string) ([]byte, error) {
 	resourcesByType := map[string]map[string]interface{}{}
+	dataSourcesByType := map[string]map[string]interface{}{}
 	mapsObjects := map[string]struct{}{}
 	indexRe := regexp.MustCompile(`\.[0-9]+`)
+	// of resources with the same address the one with the lowest ID is printed, whatever their order
+	printedIDs := map[string]string{}
 	for _, res := range resources {
-		r := resourcesByType[res.InstanceInfo.Type]
+		byType := resourcesByType
+		if res.DataSource {
+			byType = dataSourcesByType
+		}
+		r := byType[res.InstanceInfo.Type]
 		if r == nil {
 			r = make(map[string]interface{})
-			resourcesByType[res.InstanceInfo.Type] = r
+			byType[res.InstanceInfo.Type] = r
 		}
 
-		if r[res.ResourceName] != nil {
-			log.Println(resources)
-			log.Printf("[ERR]: duplicate resource found: %s.%s", res.InstanceInfo.Type, res.ResourceName)
-			continue
-		}
-
-		r[res.ResourceName] = res.Item
-
 		for k := range res.InstanceState.Attributes {
 			if strings.HasSuffix(k, ".%") {
 				key := strings.TrimSuffix(k, ".%")
 				mapsObjects[indexRe.ReplaceAllString(key, "")] = struct{}{}
 			}
 		}
+
+		address := res.Address()
+		if printedID, exist := printedIDs[address]; exist {
+			log.Printf("[ERR]: duplicate resource found: %s.%s", res.InstanceInfo.Type, res.ResourceName)
+			if printedID <= res.InstanceState.ID {
+				continue
+			}
+		}
+		printedIDs[address] = res.InstanceState.ID
+
+		item := res.Item
+		if res.ProviderAlias != "" {
+			item = make(map[string]interface{}, len(res.Item)+1)
+			for k, v := range res.Item {
+				item[k] = v
+			}
+			// provider takes a provider reference, not a string
+			item["provider"] = Expression(res.Provider + "." + res.ProviderAlias)
+		}
+		r[res.ResourceName] = item
 	}
 
 	data := map[string]interface{}{}
 	if len(resourcesByType) > 0 {
 		data["resource"] = resourcesByType
 	}
+	if len(dataSourcesByType) > 0 {
+		data["data"] = dataSourcesByType
+	}
 	if len(providerData) > 0 {
 		data["provider"] = providerData
 	}
@@ -52,3 +52,48 @@ func TestPrintResource(t *testing.T) {
 		t.Errorf("failed to parse data %s", string(data))
 	}
 }
+
+func TestPrintResourceWithProviderAlias(t *testing.T) {
+	r := prepare("ID1", "type1", map[string]string{"name": "one"}, map[string]interface{}{"name": "one"})
+	r.Provider, r.ProviderAlias = "aws", "eu_west_1"
+	data, err := HclPrintResource([]Resource{r}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "provider = aws.eu_west_1\n") {
+		t.Errorf("missing provider reference in %s", data)
+	}
+	// provider attributes of resources are values read from the cloud, they stay strings
+	other := prepare("ID2", "type2", map[string]string{"provider": "aws.sso"}, map[string]interface{}{"provider": "aws.sso"})
+	data, err = HclPrintResource([]Resource{other}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `provider = "aws.sso"`) {
+		t.Errorf("provider attribute was unquoted in %s", data)
+	}
+	state := NewTfState([]Resource{r})
+	if provider := state.Modules[0].Resources["type1."+r.ResourceName].Provider; provider != "provider.aws.eu_west_1" {
+		t.Errorf("state provider %s, expected provider.aws.eu_west_1", provider)
+	}
+}
+
+func TestPrintResourceDuplicates(t *testing.T) {
+	first := prepare("ID1", "type1", map[string]string{"name": "first"}, map[string]interface{}{"name": "first"})
+	second := prepare("ID2", "type1", map[string]string{"name": "second"}, map[string]interface{}{"name": "second"})
+	second.ResourceName = first.ResourceName
+	data, err := HclPrintResource([]Resource{second, first}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed, err := HclPrintResource([]Resource{first, second}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(reversed) {
+		t.Errorf("output depends on order:\n%s\n%s", data, reversed)
+	}
+	if !strings.Contains(string(data), `name = "first"`) {
+		t.Errorf("expected the resource with the lowest ID in %s", data)
+	}
+}
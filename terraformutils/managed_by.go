@@ -0,0 +1,150 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformerstring"
+)
+
+// ManagedByRule recognizes resources another controller owns, like the resources of a CloudFormation stack,
+// by a tag, label or attribute
+type ManagedByRule struct {
+	// Provider and Types limit the rule, empty for all
+	Provider string
+	Types    []string
+	// Tag is the key of a tag or label, Attribute a flat attribute key, one of them is set
+	Tag       string
+	Attribute string
+	// Value is matched by the value of the tag or attribute, nil for any value
+	Value *regexp.Regexp
+	// Owner describes the controller, the value is appended for tags
+	Owner string
+}
+
+// DefaultManagedByRules are the built-in rules, resources which generators know to be managed
+// set Resource.ManagedBy themselves
+var DefaultManagedByRules = []ManagedByRule{
+	{Provider: "aws", Tag: "aws:cloudformation:stack-name", Owner: "CloudFormation stack"},
+	{Provider: "aws", Tag: "aws:eks:cluster-name", Owner: "EKS cluster"},
+	{Provider: "aws", Tag: "eks:nodegroup-name", Owner: "EKS node group"},
+	{Provider: "aws", Tag: "karpenter.sh/nodepool", Owner: "Karpenter node pool"},
+	{Provider: "aws", Tag: "karpenter.sh/provisioner-name", Owner: "Karpenter provisioner"},
+	{Provider: "aws", Tag: "karpenter.k8s.aws/ec2nodeclass", Owner: "Karpenter node class"},
+	{Provider: "azurerm", Attribute: "resource_group_name", Value: regexp.MustCompile(`(?i)^MC_`), Owner: "AKS managed resource group"},
+	{Provider: "azurerm", Types: []string{"azurerm_resource_group"}, Attribute: "name", Value: regexp.MustCompile(`(?i)^MC_`), Owner: "AKS cluster"},
+	{Provider: "google", Tag: "goog-gke-node", Owner: "GKE node pool"},
+}
+
+// ParseManagedByTags parses key or key=regexp rules of tags and labels, for all providers
+func ParseManagedByTags(tags []string) ([]ManagedByRule, error) {
+	var rules []ManagedByRule
+	for _, tag := range tags {
+		rule := ManagedByRule{Tag: tag, Owner: "tag " + tag}
+		if i := strings.Index(tag, "="); i > 0 {
+			value, err := regexp.Compile(tag[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid managed-by tag %s: %v", tag, err)
+			}
+			rule.Tag, rule.Owner, rule.Value = tag[:i], "tag "+tag[:i], value
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// tagAttributes are the flat attribute keys of a tag or label in the resources of the providers
+func tagAttributes(tag string) []string {
+	return []string{"tags." + tag, "tags_all." + tag, "labels." + tag, "metadata.0.labels." + tag}
+}
+
+// Match returns the owner of the resource, or false when the rule doesn't apply
+func (r ManagedByRule) Match(resource Resource) (string, bool) {
+	if r.Provider != "" && r.Provider != resource.Provider {
+		return "", false
+	}
+	if len(r.Types) > 0 && !terraformerstring.ContainsString(r.Types, resource.InstanceInfo.Type) {
+		return "", false
+	}
+	if resource.InstanceState == nil {
+		return "", false
+	}
+	keys := []string{r.Attribute}
+	if r.Tag != "" {
+		keys = tagAttributes(r.Tag)
+	}
+	for _, key := range keys {
+		value, exist := resource.InstanceState.Attributes[key]
+		if !exist || (r.Value != nil && !r.Value.MatchString(value)) {
+			continue
+		}
+		if r.Tag != "" && value != "" {
+			return r.Owner + " " + value, true
+		}
+		return r.Owner, true
+	}
+	return "", false
+}
+
+// ManagedBy returns the owner of the resource set by its generator or found by the rules,
+// empty for resources nothing else manages
+func ManagedBy(resource Resource, rules []ManagedByRule) string {
+	if resource.ManagedBy != "" {
+		return resource.ManagedBy
+	}
+	for _, rule := range rules {
+		if owner, ok := rule.Match(resource); ok {
+			return owner
+		}
+	}
+	return ""
+}
+
+// ExcludeManagedBy drops resources owned by other controllers, they are added to the report
+func ExcludeManagedBy(resources []Resource, rules []ManagedByRule, report *ImportReport) []Resource {
+	kept := []Resource{}
+	for _, resource := range resources {
+		owner := ManagedBy(resource, rules)
+		if owner == "" {
+			kept = append(kept, resource)
+			continue
+		}
+		log.Printf("excluding %s, it is managed by %s", resource.InstanceInfo.Id, owner)
+		if report != nil {
+			report.AddExcluded(resource.InstanceInfo.Id, "managed by "+owner)
+		}
+	}
+	return kept
+}
+
+// ExcludeManagedBy drops resources owned by other controllers, once their tags are known after refresh
+func (p *ProvidersMapping) ExcludeManagedBy(rules []ManagedByRule, report *ImportReport) {
+	resources := []*Resource{}
+	for resource := range p.Resources {
+		if owner := ManagedBy(*resource, rules); owner != "" {
+			log.Printf("excluding %s, it is managed by %s", resource.InstanceInfo.Id, owner)
+			if report != nil {
+				report.AddExcluded(resource.InstanceInfo.Id, "managed by "+owner)
+			}
+			continue
+		}
+		resources = append(resources, resource)
+	}
+	p.SetResources(resources)
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExcludeManagedBy(t *testing.T) {
+	userRules, err := ParseManagedByTags([]string{"crossplane.io/claim-name", "owner=^platform-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := append(append([]ManagedByRule{}, DefaultManagedByRules...), userRules...)
+
+	stack := NewResource("sg-1", "stack", "aws_security_group", "aws", map[string]string{"tags.aws:cloudformation:stack-name": "network"}, []string{}, nil)
+	aks := NewResource("/subscriptions/s/resourceGroups/MC_rg_aks_westeurope/disks/d", "disk", "azurerm_managed_disk", "azurerm", map[string]string{"resource_group_name": "MC_rg_aks_westeurope"}, []string{}, nil)
+	claim := NewResource("bucket", "bucket", "google_storage_bucket", "google", map[string]string{"labels.crossplane.io/claim-name": "data"}, []string{}, nil)
+	platform := NewResource("i-1", "platform", "aws_instance", "aws", map[string]string{"tags.owner": "platform-team"}, []string{}, nil)
+	owned := NewSimpleResource("default/web-1234", "web-1234", "kubernetes_replica_set", "kubernetes", []string{})
+	owned.ManagedBy = "Deployment web"
+	other := NewResource("i-2", "app", "aws_instance", "aws", map[string]string{"tags.owner": "app-team", "tags.Name": "app"}, []string{}, nil)
+	// rules of other providers don't apply
+	azureLike := NewResource("rg", "rg", "aws_resourcegroups_group", "aws", map[string]string{"resource_group_name": "MC_rg"}, []string{}, nil)
+
+	report := NewImportReport()
+	kept := ExcludeManagedBy([]Resource{stack, aks, claim, platform, owned, other, azureLike}, rules, report)
+	if len(kept) != 2 || kept[0].InstanceState.ID != "i-2" || kept[1].InstanceState.ID != "rg" {
+		t.Errorf("unexpected resources %v", kept)
+	}
+	expected := map[string][]string{
+		"aws_security_group.tfer--stack":        {"managed by CloudFormation stack network"},
+		"azurerm_managed_disk.tfer--disk":       {"managed by AKS managed resource group"},
+		"google_storage_bucket.tfer--bucket":    {"managed by tag crossplane.io/claim-name data"},
+		"aws_instance.tfer--platform":           {"managed by tag owner platform-team"},
+		"kubernetes_replica_set.tfer--web-1234": {"managed by Deployment web"},
+	}
+	if !reflect.DeepEqual(report.Excluded, expected) {
+		t.Errorf("unexpected report %v", report.Excluded)
+	}
+
+	if _, err := ParseManagedByTags([]string{"owner=("}); err == nil {
+		t.Error("invalid regexp was accepted")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ProgressEnv makes imports write progress events to stderr, terraformer serve reads them from its jobs
+const ProgressEnv = "TERRAFORMER_PROGRESS"
+
+const progressPrefix = "terraformer-progress: "
+
+const (
+	ProgressDiscovered = "discovered"
+	ProgressRefreshed  = "refreshed"
+	ProgressFailed     = "failed"
+)
+
+// ProgressEvent counts resources of a provider which reached a stage of the import
+type ProgressEvent struct {
+	Event    string `json:"event"`
+	Provider string `json:"provider"`
+	Service  string `json:"service,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// ReportProgress writes the event as a line of stderr when ProgressEnv is set
+func ReportProgress(event, provider, service string, count int) {
+	if os.Getenv(ProgressEnv) == "" {
+		return
+	}
+	data, err := json.Marshal(ProgressEvent{Event: event, Provider: provider, Service: service, Count: count})
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, progressPrefix+string(data))
+}
+
+// ParseProgress reads an event written by ReportProgress from a line of output
+func ParseProgress(line string) (ProgressEvent, bool) {
+	i := strings.Index(line, progressPrefix)
+	if i < 0 {
+		return ProgressEvent{}, false
+	}
+	var event ProgressEvent
+	if err := json.Unmarshal([]byte(line[i+len(progressPrefix):]), &event); err != nil {
+		return ProgressEvent{}, false
+	}
+	return event, true
+}
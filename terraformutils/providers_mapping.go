@@ -4,9 +4,12 @@ import (
 	"log"
 	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformerstring"
 )
 
 type ProvidersMapping struct {
@@ -71,6 +74,8 @@ func (p *ProvidersMapping) RemoveServices(services []string) {
 	}
 }
 
+// ShuffleResources returns the resources in random order, refreshing them in order of address
+// would read resources of the same API one after another and get throttled
 func (p *ProvidersMapping) ShuffleResources() []*Resource {
 	resources := []*Resource{}
 	for resource := range p.Resources {
@@ -82,6 +87,24 @@ func (p *ProvidersMapping) ShuffleResources() []*Resource {
 	return resources
 }
 
+// SortedResources returns the resources ordered by address and ID, so output doesn't depend on
+// map iteration order
+func (p *ProvidersMapping) SortedResources() []*Resource {
+	resources := []*Resource{}
+	for resource := range p.Resources {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool { return lessResource(*resources[i], *resources[j]) })
+	return resources
+}
+
+func lessResource(a, b Resource) bool {
+	if a.InstanceInfo.Id != b.InstanceInfo.Id {
+		return a.InstanceInfo.Id < b.InstanceInfo.Id
+	}
+	return a.InstanceState.ID < b.InstanceState.ID
+}
+
 func (p *ProvidersMapping) ProcessResources(isCleanup bool) {
 	initialResources := p.resourceToProvider
 	if isCleanup && len(initialResources) > 0 {
@@ -137,7 +160,7 @@ func (p *ProvidersMapping) GetResourcesByService() map[string][]Resource {
 		mapping[service] = []Resource{}
 	}
 
-	for resource := range p.Resources {
+	for _, resource := range p.SortedResources() {
 		provider := p.resourceToProvider[resource]
 		service := p.providerToService[provider]
 		mapping[service] = append(mapping[service], *resource)
@@ -155,7 +178,7 @@ func (p *ProvidersMapping) ConvertTFStates(providerWrapper *providerwrapper.Prov
 	}
 
 	resourcesGroupsByProviders := map[ProviderGenerator][]Resource{}
-	for resource := range p.Resources {
+	for _, resource := range p.SortedResources() {
 		provider := p.resourceToProvider[resource]
 		if resourcesGroupsByProviders[provider] == nil {
 			resourcesGroupsByProviders[provider] = []Resource{}
@@ -169,6 +192,53 @@ func (p *ProvidersMapping) ConvertTFStates(providerWrapper *providerwrapper.Prov
 
 }
 
+// ConvertDataSources turns resources of the given services or types into data sources
+func (p *ProvidersMapping) ConvertDataSources(providerWrapper *providerwrapper.ProviderWrapper, servicesOrTypes []string) {
+	dataSources := providerWrapper.GetSchema().DataSources
+	for resource := range p.Resources {
+		service := p.providerToService[p.resourceToProvider[resource]]
+		if !terraformerstring.ContainsString(servicesOrTypes, service) && !terraformerstring.ContainsString(servicesOrTypes, resource.InstanceInfo.Type) {
+			continue
+		}
+		schema, exist := dataSources[resource.InstanceInfo.Type]
+		if !exist {
+			log.Printf("no data source %s, keep %s as resource", resource.InstanceInfo.Type, resource.InstanceInfo.Id)
+			continue
+		}
+		if err := resource.AsDataSource(schema.Block); err != nil {
+			log.Printf("keep %s as resource because of error %s", resource.InstanceInfo.Id, err)
+		}
+	}
+}
+
+// ValidateResources validates generated configs with the provider and adds findings to report
+func (p *ProvidersMapping) ValidateResources(providerWrapper *providerwrapper.ProviderWrapper, report *ImportReport) {
+	for resource := range p.Resources {
+		dropped, errs := resource.Validate(providerWrapper)
+		for _, err := range errs {
+			log.Printf("invalid resource %s: %s", resource.InstanceInfo.Id, err)
+		}
+		report.AddValidation(resource.InstanceInfo.Id, dropped, errs)
+	}
+}
+
+// VerifyResources plans every generated config against its refreshed state and
+// ignores changes of attributes which would still differ after import
+func (p *ProvidersMapping) VerifyResources(providerWrapper *providerwrapper.ProviderWrapper, report *ImportReport) {
+	for resource := range p.Resources {
+		changed, err := resource.PlanDiff(providerWrapper)
+		if err != nil {
+			log.Printf("failed to plan resource %s because of error %s", resource.InstanceInfo.Id, err)
+			continue
+		}
+		if len(changed) > 0 {
+			log.Printf("resource %s still differs in %s, ignoring changes", resource.InstanceInfo.Id, strings.Join(changed, ", "))
+		}
+		resource.IgnoreChanges(changed)
+		report.AddIgnoredChanges(resource.InstanceInfo.Id, changed)
+	}
+}
+
 func (p *ProvidersMapping) CleanupProviders() {
 	for provider := range p.Providers {
 		provider.GetService().PostRefreshCleanup()
@@ -0,0 +1,49 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestShuffleAndSortedResources(t *testing.T) {
+	p := NewProvidersMapping(nil)
+	for i := 0; i < 50; i++ {
+		r := NewSimpleResource(fmt.Sprintf("vpc-%02d", i), fmt.Sprintf("vpc%02d", i), "aws_vpc", "aws", []string{})
+		p.Resources[&r] = true
+	}
+	sorted := p.SortedResources()
+	for i := 1; i < len(sorted); i++ {
+		if !lessResource(*sorted[i-1], *sorted[i]) {
+			t.Fatalf("%s is sorted before %s", sorted[i-1].InstanceInfo.Id, sorted[i].InstanceInfo.Id)
+		}
+	}
+
+	// refresh order is random, so reads of an API are spread out
+	shuffled := p.ShuffleResources()
+	if len(shuffled) != len(sorted) {
+		t.Fatalf("expected %d resources, got %d", len(sorted), len(shuffled))
+	}
+	for _, r := range shuffled {
+		if !p.Resources[r] {
+			t.Fatalf("unknown resource %s", r.InstanceInfo.Id)
+		}
+	}
+	if reflect.DeepEqual(shuffled, sorted) && reflect.DeepEqual(p.ShuffleResources(), sorted) {
+		t.Error("resources are shuffled in address order")
+	}
+}
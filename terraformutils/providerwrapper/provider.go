@@ -23,18 +23,24 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformerstring"
 
 	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/plans/objchange"
 	tfplugin "github.com/hashicorp/terraform/plugin"
 	"github.com/hashicorp/terraform/providers"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
 	"github.com/hashicorp/terraform/version"
 )
 
@@ -50,9 +56,10 @@ const DefaultPluginVendorDirV12 = "terraform.d/plugins/" + pluginMachineName
 const pluginMachineName = runtime.GOOS + "_" + runtime.GOARCH
 
 type ProviderWrapper struct {
-	Provider     *tfplugin.GRPCProvider
+	Provider     providers.Interface
 	client       *plugin.Client
 	rpcClient    plugin.ClientProtocol
+	poolKey      string
 	providerName string
 	config       cty.Value
 	schema       *providers.GetSchemaResponse
@@ -76,13 +83,108 @@ func NewProviderWrapper(providerName string, providerConfig cty.Value, verbose b
 		}
 	}
 
-	err := p.initProvider(verbose)
+	if r := recorder.Active(); r != nil && r.Mode() == recorder.Replay {
+		p.Provider = r.Provider(nil)
+		return p, nil
+	}
+
+	err := p.acquire(verbose)
 
 	return p, err
 }
 
+// Kill releases the plugin instance of the wrapper, the instance stays in the pool until ClosePool
 func (p *ProviderWrapper) Kill() {
-	p.client.Kill()
+	if p.poolKey == "" {
+		return
+	}
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if instance, exist := pool[p.poolKey]; exist && instance.refs > 0 {
+		instance.refs--
+	}
+	p.poolKey = ""
+}
+
+// pooledPlugin is a configured plugin instance shared by wrappers of the same provider and config
+type pooledPlugin struct {
+	providerName string
+	ready        chan struct{}
+	err          error
+	client       *plugin.Client
+	rpcClient    plugin.ClientProtocol
+	provider     providers.Interface
+	refs         int
+}
+
+var (
+	poolMu sync.Mutex
+	// plugins live for the whole command, wrappers with the same config don't launch them again
+	pool = map[string]*pooledPlugin{}
+)
+
+func configPoolKey(providerName string, config cty.Value) string {
+	if config.IsNull() {
+		return providerName
+	}
+	data, err := ctyjson.Marshal(config, config.Type())
+	if err != nil {
+		return providerName + " " + config.GoString()
+	}
+	return providerName + " " + string(data)
+}
+
+// acquire takes the plugin instance with the config of the wrapper from the pool or launches it.
+// Idle instances of the provider with another config are killed, every region would keep one otherwise.
+func (p *ProviderWrapper) acquire(verbose bool) error {
+	key := configPoolKey(p.providerName, p.config)
+	poolMu.Lock()
+	instance, exist := pool[key]
+	if !exist {
+		for otherKey, other := range pool {
+			if other.providerName == p.providerName && other.refs == 0 && other.client != nil {
+				other.client.Kill()
+				delete(pool, otherKey)
+			}
+		}
+		instance = &pooledPlugin{providerName: p.providerName, ready: make(chan struct{})}
+		pool[key] = instance
+	}
+	instance.refs++
+	poolMu.Unlock()
+
+	if exist {
+		<-instance.ready
+	} else {
+		instance.err = p.initProvider(verbose)
+		poolMu.Lock()
+		instance.client, instance.rpcClient, instance.provider = p.client, p.rpcClient, p.Provider
+		poolMu.Unlock()
+		close(instance.ready)
+	}
+	if instance.err != nil {
+		poolMu.Lock()
+		if pool[key] == instance {
+			delete(pool, key)
+		}
+		poolMu.Unlock()
+		return instance.err
+	}
+	p.client, p.rpcClient, p.Provider = instance.client, instance.rpcClient, instance.provider
+	p.poolKey = key
+	return nil
+}
+
+// ClosePool kills all plugin instances, it's called once the command is done
+func ClosePool() {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	for key, instance := range pool {
+		if instance.client != nil {
+			instance.client.Kill()
+		}
+		delete(pool, key)
+	}
 }
 
 func (p *ProviderWrapper) GetSchema() *providers.GetSchemaResponse {
@@ -208,6 +310,64 @@ func (p *ProviderWrapper) Refresh(info *terraform.InstanceInfo, state *terraform
 	return terraform.NewInstanceStateShimmedFromValue(resp.NewState, int(schema.ResourceTypes[info.Type].Version)), nil
 }
 
+// Import imports a resource by ID and reads the imported state, like terraform import does
+func (p *ProviderWrapper) Import(info *terraform.InstanceInfo, id string) (*terraform.InstanceState, error) {
+	schema, exist := p.GetSchema().ResourceTypes[info.Type]
+	if !exist {
+		return nil, fmt.Errorf("provider %s has no resource type %s", p.providerName, info.Type)
+	}
+	importResponse := p.Provider.ImportResourceState(providers.ImportResourceStateRequest{
+		TypeName: info.Type,
+		ID:       id,
+	})
+	if importResponse.Diagnostics.HasErrors() {
+		return nil, importResponse.Diagnostics.Err()
+	}
+	// importers may return related resources of other types too
+	var imported *providers.ImportedResource
+	for i := range importResponse.ImportedResources {
+		if importResponse.ImportedResources[i].TypeName == info.Type {
+			imported = &importResponse.ImportedResources[i]
+			break
+		}
+	}
+	if imported == nil {
+		return nil, errors.New("not able to import resource for a given ID")
+	}
+	resp := p.Provider.ReadResource(providers.ReadResourceRequest{
+		TypeName:   info.Type,
+		PriorState: imported.State,
+		Private:    imported.Private,
+	})
+	if resp.Diagnostics.HasErrors() {
+		return nil, resp.Diagnostics.Err()
+	}
+	if resp.NewState.IsNull() {
+		return nil, fmt.Errorf("resource %s with ID %s doesn't exist", info.Type, id)
+	}
+	return terraform.NewInstanceStateShimmedFromValue(resp.NewState, int(schema.Version)), nil
+}
+
+func (p *ProviderWrapper) ValidateResource(resourceType string, config cty.Value) tfdiags.Diagnostics {
+	resp := p.Provider.ValidateResourceTypeConfig(providers.ValidateResourceTypeConfigRequest{
+		TypeName: resourceType,
+		Config:   config,
+	})
+	return resp.Diagnostics
+}
+
+// PlanResource plans the change from the refreshed state to config, like terraform plan does
+func (p *ProviderWrapper) PlanResource(resourceType string, priorState, config cty.Value) (cty.Value, tfdiags.Diagnostics) {
+	schema := p.GetSchema().ResourceTypes[resourceType]
+	resp := p.Provider.PlanResourceChange(providers.PlanResourceChangeRequest{
+		TypeName:         resourceType,
+		PriorState:       priorState,
+		ProposedNewState: objchange.ProposedNewObject(schema.Block, priorState, config),
+		Config:           config,
+	})
+	return resp.PlannedState, resp.Diagnostics
+}
+
 func (p *ProviderWrapper) initProvider(verbose bool) error {
 	providerFilePath, err := getProviderFileName(p.providerName)
 	if err != nil {
@@ -226,25 +386,39 @@ func (p *ProviderWrapper) initProvider(verbose bool) error {
 		&plugin.ClientConfig{
 			Cmd:              exec.Command(providerFilePath),
 			HandshakeConfig:  tfplugin.Handshake,
-			VersionedPlugins: tfplugin.VersionedPlugins,
+			VersionedPlugins: versionedPlugins,
 			Managed:          true,
 			Logger:           logger,
 			AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
 			AutoMTLS:         true,
+			GRPCDialOptions:  schemaCacheDialOptions(p.providerName, providerFilePath),
 		})
 	p.rpcClient, err = p.client.Client()
 	if err != nil {
+		p.client.Kill()
 		return err
 	}
 	raw, err := p.rpcClient.Dispense(tfplugin.ProviderPluginName)
 	if err != nil {
+		p.client.Kill()
 		return err
 	}
 
-	p.Provider = raw.(*tfplugin.GRPCProvider)
+	provider, ok := raw.(providers.Interface)
+	if !ok {
+		p.client.Kill()
+		return fmt.Errorf("unsupported plugin of provider %s with protocol version %d", p.providerName, p.client.NegotiatedVersion())
+	}
+	p.Provider = provider
+	if r := recorder.Active(); r != nil {
+		address, _ := GetProviderAddress(p.providerName)
+		r.SetProvider(p.providerName, address.Source(), address.Version)
+		p.Provider = r.Provider(p.Provider)
+	}
 
 	config, err := p.GetSchema().Provider.Block.CoerceValue(p.config)
 	if err != nil {
+		p.client.Kill()
 		return err
 	}
 	p.Provider.Configure(providers.ConfigureRequest{
@@ -272,14 +446,25 @@ func getProviderFileName(providerName string) (string, error) {
 }
 
 func getProviderFileNameV13andV14(prefix, providerName string) (string, error) {
+	var err error
+	for _, host := range registryHosts() {
+		var providerFilePath string
+		providerFilePath, err = getRegistryProviderFileName(prefix, host, providerName)
+		if err == nil && providerFilePath != "" {
+			return providerFilePath, nil
+		}
+	}
+	return "", err
+}
+
+// getRegistryProviderFileName looks for the plugin in the directory of a registry host
+func getRegistryProviderFileName(prefix, host, providerName string) (string, error) {
 	// Read terraform v14 file path
-	registryDir := prefix + string(os.PathSeparator) + "providers" + string(os.PathSeparator) +
-		"registry.terraform.io"
+	registryDir := prefix + string(os.PathSeparator) + "providers" + string(os.PathSeparator) + host
 	providerDirs, err := ioutil.ReadDir(registryDir)
 	if err != nil {
 		// Read terraform v13 file path
-		registryDir = prefix + string(os.PathSeparator) + "plugins" + string(os.PathSeparator) +
-			"registry.terraform.io"
+		registryDir = prefix + string(os.PathSeparator) + "plugins" + string(os.PathSeparator) + host
 		providerDirs, err = ioutil.ReadDir(registryDir)
 		if err != nil {
 			return "", err
@@ -340,19 +525,143 @@ func getProviderFileNameV12(providerName string) (string, error) {
 	return providerFilePath, nil
 }
 
-func GetProviderVersion(providerName string) string {
-	providerFilePath, err := getProviderFileName(providerName)
-	if err != nil {
-		log.Println("Can't find provider file path. Ensure that you are following https://www.terraform.io/docs/configuration/providers.html#third-party-plugins.")
+// ProviderAddress is where an installed provider plugin comes from.
+// Namespace and Hostname are unknown for plugins of the legacy plugin directory.
+type ProviderAddress struct {
+	Hostname  string
+	Namespace string
+	Type      string
+	Version   string
+}
+
+const (
+	DefaultRegistryHost  = "registry.terraform.io"
+	OpenTofuRegistryHost = "registry.opentofu.org"
+)
+
+// registryHost is the host of generated provider addresses, see SetRegistryHost
+var registryHost atomic.Value
+
+// SetRegistryHost makes the host the one of generated source and state provider addresses, plugins
+// are looked up in its directory first. Empty keeps the host of the directory the plugin is installed in
+func SetRegistryHost(host string) {
+	registryHost.Store(host)
+}
+
+func getRegistryHost() string {
+	host, _ := registryHost.Load().(string)
+	return host
+}
+
+// registryHosts are the plugin directories of registries, Terraform and OpenTofu install into their own
+func registryHosts() []string {
+	host := getRegistryHost()
+	hosts := []string{}
+	if host != "" {
+		hosts = append(hosts, host)
+	}
+	for _, h := range []string{DefaultRegistryHost, OpenTofuRegistryHost} {
+		if h != host {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// Source returns the source address for required_providers, or empty without namespace
+func (a ProviderAddress) Source() string {
+	switch {
+	case a.Namespace == "":
 		return ""
+	case a.Hostname == "" || a.Hostname == DefaultRegistryHost:
+		return a.Namespace + "/" + a.Type
 	}
+	return a.Hostname + "/" + a.Namespace + "/" + a.Type
+}
+
+// FullSource returns the source address with hostname, as in state provider addresses, or empty without namespace
+func (a ProviderAddress) FullSource() string {
+	switch {
+	case a.Namespace == "":
+		return ""
+	case a.Hostname == "":
+		return DefaultRegistryHost + "/" + a.Namespace + "/" + a.Type
+	}
+	return a.Hostname + "/" + a.Namespace + "/" + a.Type
+}
+
+// VersionConstraint allows patch releases of the installed version
+func (a ProviderAddress) VersionConstraint() string {
+	if a.Version == "" {
+		return ""
+	}
+	return "~> " + a.Version
+}
+
+// providerAddressFromPath reads the address from <hostname>/<namespace>/<type>/<version>/<os>_<arch>/<file>
+// or the version from terraform-provider-<type>_v<version> file names of the legacy plugin directory
+func providerAddressFromPath(providerName, providerFilePath string) (ProviderAddress, error) {
 	t := strings.Split(providerFilePath, string(os.PathSeparator))
+	if len(t) >= 6 && t[len(t)-2] == pluginMachineName && t[len(t)-4] == providerName {
+		return ProviderAddress{
+			Hostname:  t[len(t)-6],
+			Namespace: t[len(t)-5],
+			Type:      providerName,
+			Version:   t[len(t)-3],
+		}, nil
+	}
 	providerFileName := t[len(t)-1]
 	providerFileNameParts := strings.Split(providerFileName, "_")
 	if len(providerFileNameParts) < 2 {
+		return ProviderAddress{Type: providerName}, errors.New("can't find provider version in " + providerFileName)
+	}
+	return ProviderAddress{
+		Type:    providerName,
+		Version: strings.TrimPrefix(providerFileNameParts[1], "v"),
+	}, nil
+}
+
+// GetProviderAddress returns the address of the installed plugin of the provider
+func GetProviderAddress(providerName string) (ProviderAddress, error) {
+	if r := recorder.Active(); r != nil && r.Mode() == recorder.Replay {
+		return replayedProviderAddress(providerName, r), nil
+	}
+	providerFilePath, err := getProviderFileName(providerName)
+	if err != nil {
+		return ProviderAddress{Type: providerName}, err
+	}
+	address, err := providerAddressFromPath(providerName, providerFilePath)
+	if host := getRegistryHost(); host != "" && address.Namespace != "" {
+		address.Hostname = host
+	}
+	return address, err
+}
+
+func replayedProviderAddress(providerName string, r *recorder.Recorder) ProviderAddress {
+	source, version := r.ProviderAddress()
+	address := ProviderAddress{Type: providerName, Version: version}
+	switch parts := strings.Split(source, "/"); len(parts) {
+	case 2:
+		address.Hostname, address.Namespace = DefaultRegistryHost, parts[0]
+	case 3:
+		address.Hostname, address.Namespace = parts[0], parts[1]
+	}
+	return address
+}
+
+func GetProviderVersion(providerName string) string {
+	if r := recorder.Active(); r != nil && r.Mode() == recorder.Replay {
+		return replayedProviderAddress(providerName, r).VersionConstraint()
+	}
+	providerFilePath, err := getProviderFileName(providerName)
+	if err != nil {
+		log.Println("Can't find provider file path. Ensure that you are following https://www.terraform.io/docs/configuration/providers.html#third-party-plugins.")
+		return ""
+	}
+	address, err := providerAddressFromPath(providerName, providerFilePath)
+	if err != nil {
 		log.Println("Can't find provider version. Ensure that you are following https://www.terraform.io/docs/configuration/providers.html#plugin-names-and-versions.")
 		return ""
 	}
-	providerVersion := providerFileNameParts[1]
-	return "~> " + strings.TrimPrefix(providerVersion, "v")
+	return address.VersionConstraint()
 }
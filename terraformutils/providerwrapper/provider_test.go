@@ -1,6 +1,9 @@
 package providerwrapper //nolint
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -82,3 +85,61 @@ func isAttributeIgnored(name string, patterns []string) bool {
 	}
 	return ignored
 }
+
+func TestProviderAddressFromPath(t *testing.T) {
+	testCases := map[string]struct {
+		path    string
+		source  string
+		version string
+	}{
+		"registry": {
+			filepath.Join("/root/.terraform.d/plugins/registry.terraform.io/integrations/github/4.9.2", pluginMachineName, "terraform-provider-github_v4.9.2"),
+			"integrations/github", "~> 4.9.2",
+		},
+		"other_host": {
+			filepath.Join("/plugins/example.com/acme/github/1.0.0", pluginMachineName, "terraform-provider-github"),
+			"example.com/acme/github", "~> 1.0.0",
+		},
+		"legacy": {
+			filepath.Join("/root/.terraform.d/plugins", pluginMachineName, "terraform-provider-github_v2.2.0_x4"),
+			"", "~> 2.2.0",
+		},
+	}
+	for name, testCase := range testCases {
+		address, err := providerAddressFromPath("github", testCase.path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if address.Source() != testCase.source || address.VersionConstraint() != testCase.version {
+			t.Errorf("%s: got %q %q, expected %q %q", name, address.Source(), address.VersionConstraint(), testCase.source, testCase.version)
+		}
+	}
+}
+
+func TestGetProviderAddressOpenTofu(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "providers", OpenTofuRegistryHost, "hashicorp", "aws", "5.0.0", pluginMachineName)
+	if err := os.MkdirAll(pluginDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(pluginDir, "terraform-provider-aws_v5.0.0"), nil, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TF_DATA_DIR", dir)
+	defer SetRegistryHost("")
+
+	for host, expected := range map[string]string{
+		"":                  "registry.opentofu.org/hashicorp/aws",
+		"tofu.example.com":  "tofu.example.com/hashicorp/aws",
+		DefaultRegistryHost: "hashicorp/aws",
+	} {
+		SetRegistryHost(host)
+		address, err := GetProviderAddress("aws")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address.Source() != expected || address.Version != "5.0.0" {
+			t.Errorf("registry host %q: got %q %q, expected %q", host, address.Source(), address.Version, expected)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerwrapper //nolint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-plugin"
+	tfplugin "github.com/hashicorp/terraform/plugin"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Providers may generate large schemas, like Terraform we accept up to 64MB
+const maxSchemaRecvSize = 64 << 20
+
+// versionedPlugins offers protocol v5 and v6, go-plugin negotiates the highest version the plugin speaks
+var versionedPlugins = map[int]plugin.PluginSet{
+	5: tfplugin.VersionedPlugins[5],
+	6: {tfplugin.ProviderPluginName: &GRPCProviderV6Plugin{}},
+}
+
+// GRPCProviderV6Plugin is the client side of providers speaking plugin protocol v6
+type GRPCProviderV6Plugin struct {
+	plugin.Plugin
+}
+
+func (p *GRPCProviderV6Plugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCProviderV6{conn: c, ctx: ctx}, nil
+}
+
+func (p *GRPCProviderV6Plugin) GRPCServer(*plugin.GRPCBroker, *grpc.Server) error {
+	return errors.New("tfplugin6: terraformer only implements the client")
+}
+
+// GRPCProviderV6 implements providers.Interface with protocol v6 calls
+type GRPCProviderV6 struct {
+	conn grpc.ClientConnInterface
+	ctx  context.Context
+
+	mu      sync.Mutex
+	schemas *providers.GetSchemaResponse
+}
+
+func (p *GRPCProviderV6) invoke(method string, req message, opts ...grpc.CallOption) ([]byte, error) {
+	var resp []byte
+	opts = append(opts, grpc.ForceCodec(rawCodec{}))
+	err := p.conn.Invoke(p.ctx, "/tfplugin6.Provider/"+method, req, &resp, opts...)
+	return resp, err
+}
+
+// call invokes method and decodes the diagnostics of the response at field number diagnostics,
+// other fields are passed to field
+func (p *GRPCProviderV6) call(method string, req message, diagnostics protowire.Number, field func(num protowire.Number, value []byte) error) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	resp, err := p.invoke(method, req)
+	if err != nil {
+		return diags.Append(err)
+	}
+	decodeDiagnostics := diagnosticsField(&diags, diagnostics)
+	err = decodeFields(resp, func(num protowire.Number, value []byte, _ uint64) error {
+		if num == diagnostics {
+			return decodeDiagnostics(num, value)
+		}
+		if field != nil {
+			return field(num, value)
+		}
+		return nil
+	})
+	if err != nil {
+		diags = diags.Append(err)
+	}
+	return diags
+}
+
+func (p *GRPCProviderV6) GetSchema() providers.GetSchemaResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.schemas != nil {
+		return *p.schemas
+	}
+	resp, err := p.invoke("GetProviderSchema", message{}, grpc.MaxCallRecvMsgSize(maxSchemaRecvSize))
+	if err != nil {
+		var diags tfdiags.Diagnostics
+		return providers.GetSchemaResponse{Diagnostics: diags.Append(err)}
+	}
+	schemas, err := decodeProviderSchemas(resp)
+	if err != nil {
+		schemas.Diagnostics = schemas.Diagnostics.Append(err)
+		return schemas
+	}
+	p.schemas = &schemas
+	return schemas
+}
+
+func (p *GRPCProviderV6) resourceType(typeName string) (cty.Type, error) {
+	schema, exist := p.GetSchema().ResourceTypes[typeName]
+	if !exist {
+		return cty.NilType, fmt.Errorf("unknown resource type %s", typeName)
+	}
+	return schema.Block.ImpliedType(), nil
+}
+
+// PrepareProviderConfig isn't part of protocol v6, providers validate their config when configured
+func (p *GRPCProviderV6) PrepareProviderConfig(r providers.PrepareProviderConfigRequest) providers.PrepareProviderConfigResponse {
+	return providers.PrepareProviderConfigResponse{PreparedConfig: r.Config}
+}
+
+func (p *GRPCProviderV6) ValidateResourceTypeConfig(r providers.ValidateResourceTypeConfigRequest) (resp providers.ValidateResourceTypeConfigResponse) {
+	ty, err := p.resourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	config, err := encodeDynamicValue(r.Config, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = p.call("ValidateResourceConfig", message{}.string(1, r.TypeName).message(2, config), 1, nil)
+	return resp
+}
+
+func (p *GRPCProviderV6) ValidateDataSourceConfig(r providers.ValidateDataSourceConfigRequest) (resp providers.ValidateDataSourceConfigResponse) {
+	schema, exist := p.GetSchema().DataSources[r.TypeName]
+	if !exist {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("unknown data source %s", r.TypeName))
+		return resp
+	}
+	config, err := encodeDynamicValue(r.Config, schema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = p.call("ValidateDataResourceConfig", message{}.string(1, r.TypeName).message(2, config), 1, nil)
+	return resp
+}
+
+func (p *GRPCProviderV6) UpgradeResourceState(providers.UpgradeResourceStateRequest) (resp providers.UpgradeResourceStateResponse) {
+	resp.Diagnostics = resp.Diagnostics.Append(errors.New("tfplugin6: UpgradeResourceState is not used by terraformer"))
+	return resp
+}
+
+func (p *GRPCProviderV6) Configure(r providers.ConfigureRequest) (resp providers.ConfigureResponse) {
+	config, err := encodeDynamicValue(r.Config, p.GetSchema().Provider.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = p.call("ConfigureProvider", message{}.string(1, r.TerraformVersion).message(2, config), 1, nil)
+	return resp
+}
+
+func (p *GRPCProviderV6) Stop() error {
+	resp, err := p.invoke("StopProvider", message{})
+	if err != nil {
+		return err
+	}
+	return decodeFields(resp, func(num protowire.Number, value []byte, _ uint64) error {
+		if num == 1 && len(value) > 0 {
+			return errors.New(string(value))
+		}
+		return nil
+	})
+}
+
+func (p *GRPCProviderV6) ReadResource(r providers.ReadResourceRequest) (resp providers.ReadResourceResponse) {
+	ty, err := p.resourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	state, err := encodeDynamicValue(r.PriorState, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.NewState = cty.NullVal(ty)
+	req := message{}.string(1, r.TypeName).message(2, state).bytes(3, r.Private)
+	resp.Diagnostics = p.call("ReadResource", req, 2, func(num protowire.Number, value []byte) error {
+		var err error
+		switch num {
+		case 1:
+			resp.NewState, err = decodeDynamicValue(value, ty)
+		case 3:
+			resp.Private = value
+		}
+		return err
+	})
+	return resp
+}
+
+func (p *GRPCProviderV6) PlanResourceChange(r providers.PlanResourceChangeRequest) (resp providers.PlanResourceChangeResponse) {
+	ty, err := p.resourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	req := message{}.string(1, r.TypeName)
+	for i, v := range []cty.Value{r.PriorState, r.ProposedNewState, r.Config} {
+		value, err := encodeDynamicValue(v, ty)
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp
+		}
+		req = req.message(protowire.Number(i+2), value)
+	}
+	req = req.bytes(5, r.PriorPrivate)
+	resp.PlannedState = cty.NullVal(ty)
+	resp.Diagnostics = p.call("PlanResourceChange", req, 4, func(num protowire.Number, value []byte) error {
+		var err error
+		switch num {
+		case 1:
+			resp.PlannedState, err = decodeDynamicValue(value, ty)
+		case 2:
+			var path cty.Path
+			path, err = decodeAttributePath(value)
+			resp.RequiresReplace = append(resp.RequiresReplace, path)
+		case 3:
+			resp.PlannedPrivate = value
+		}
+		return err
+	})
+	return resp
+}
+
+func (p *GRPCProviderV6) ApplyResourceChange(providers.ApplyResourceChangeRequest) (resp providers.ApplyResourceChangeResponse) {
+	resp.Diagnostics = resp.Diagnostics.Append(errors.New("tfplugin6: ApplyResourceChange is not used by terraformer"))
+	return resp
+}
+
+func (p *GRPCProviderV6) ImportResourceState(r providers.ImportResourceStateRequest) (resp providers.ImportResourceStateResponse) {
+	req := message{}.string(1, r.TypeName).string(2, r.ID)
+	resp.Diagnostics = p.call("ImportResourceState", req, 2, func(num protowire.Number, value []byte) error {
+		if num != 1 {
+			return nil
+		}
+		imported := providers.ImportedResource{}
+		var state []byte
+		err := decodeFields(value, func(num protowire.Number, value []byte, _ uint64) error {
+			switch num {
+			case 1:
+				imported.TypeName = string(value)
+			case 2:
+				state = value
+			case 3:
+				imported.Private = value
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		ty, err := p.resourceType(imported.TypeName)
+		if err != nil {
+			return err
+		}
+		imported.State, err = decodeDynamicValue(state, ty)
+		resp.ImportedResources = append(resp.ImportedResources, imported)
+		return err
+	})
+	return resp
+}
+
+func (p *GRPCProviderV6) ReadDataSource(r providers.ReadDataSourceRequest) (resp providers.ReadDataSourceResponse) {
+	schema, exist := p.GetSchema().DataSources[r.TypeName]
+	if !exist {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("unknown data source %s", r.TypeName))
+		return resp
+	}
+	ty := schema.Block.ImpliedType()
+	config, err := encodeDynamicValue(r.Config, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.State = cty.NullVal(ty)
+	resp.Diagnostics = p.call("ReadDataSource", message{}.string(1, r.TypeName).message(2, config), 2, func(num protowire.Number, value []byte) error {
+		var err error
+		if num == 1 {
+			resp.State, err = decodeDynamicValue(value, ty)
+		}
+		return err
+	})
+	return resp
+}
+
+func (p *GRPCProviderV6) Close() error {
+	return nil
+}
@@ -0,0 +1,154 @@
+package providerwrapper //nolint
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func testSchemaResponse() message {
+	attribute := func(name, ty string, flag protowire.Number) message {
+		return message{}.string(1, name).bytes(2, []byte(ty)).varint(flag, 1)
+	}
+	nestedAttribute := message{}.string(1, "ports").varint(5, 1).message(10,
+		message{}.message(1, attribute("from", `"number"`, 4)).message(1, attribute("to", `"number"`, 5)).varint(3, 3))
+	ruleBlock := message{}.message(2, attribute("action", `"string"`, 4))
+	block := message{}.
+		message(2, attribute("id", `"string"`, 6)).
+		message(2, attribute("name", `"string"`, 4)).
+		message(2, attribute("tags", `["map","string"]`, 5)).
+		message(2, nestedAttribute).
+		message(3, message{}.string(1, "rule").message(2, ruleBlock).varint(3, 2).varint(5, 10))
+	resourceSchema := message{}.varint(1, 2).message(2, block)
+	providerSchema := message{}.message(2, message{}.message(2, attribute("region", `"string"`, 5)))
+	return message{}.
+		message(1, providerSchema).
+		message(2, message{}.string(1, "test_resource").message(2, resourceSchema))
+}
+
+// startServer serves plugin methods with handlers receiving and returning encoded messages
+func startServer(t *testing.T, handlers map[string]func(req []byte) message, opts ...grpc.DialOption) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		var req []byte
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		handler, exist := handlers[method]
+		if !exist {
+			t.Errorf("unexpected call of %s", method)
+			return stream.SendMsg(message{})
+		}
+		return stream.SendMsg(handler(req))
+	}))
+	go server.Serve(listener) //nolint
+	t.Cleanup(server.Stop)
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// startV6Server serves tfplugin6 methods to a protocol v6 client
+func startV6Server(t *testing.T, handlers map[string]func(req []byte) message, opts ...grpc.DialOption) *GRPCProviderV6 {
+	return &GRPCProviderV6{conn: startServer(t, handlers, opts...), ctx: context.Background()}
+}
+
+func TestGRPCProviderV6GetSchema(t *testing.T) {
+	p := startV6Server(t, map[string]func([]byte) message{
+		"/tfplugin6.Provider/GetProviderSchema": func([]byte) message { return testSchemaResponse() },
+	})
+	resp := p.GetSchema()
+	if resp.Diagnostics.HasErrors() {
+		t.Fatal(resp.Diagnostics.Err())
+	}
+	schema := resp.ResourceTypes["test_resource"]
+	if schema.Version != 2 {
+		t.Errorf("version %d, expected 2", schema.Version)
+	}
+	if !schema.Block.Attributes["id"].Computed || !schema.Block.Attributes["name"].Required || !schema.Block.Attributes["tags"].Optional {
+		t.Errorf("wrong attribute flags %v", schema.Block.Attributes)
+	}
+	ports := cty.Set(cty.Object(map[string]cty.Type{"from": cty.Number, "to": cty.Number}))
+	if !schema.Block.Attributes["ports"].Type.Equals(ports) {
+		t.Errorf("nested attribute type %s, expected %s", schema.Block.Attributes["ports"].Type.FriendlyName(), ports.FriendlyName())
+	}
+	rule := schema.Block.BlockTypes["rule"]
+	if rule == nil || rule.Nesting != configschema.NestingList || rule.MaxItems != 10 || !rule.Attributes["action"].Required {
+		t.Errorf("wrong nested block %v", rule)
+	}
+	if _, exist := resp.Provider.Block.Attributes["region"]; !exist {
+		t.Error("missing provider attribute region")
+	}
+}
+
+func TestGRPCProviderV6ReadAndImport(t *testing.T) {
+	var p *GRPCProviderV6
+	ty := func() cty.Type { return p.GetSchema().ResourceTypes["test_resource"].Block.ImpliedType() }
+	p = startV6Server(t, map[string]func([]byte) message{
+		"/tfplugin6.Provider/GetProviderSchema": func([]byte) message { return testSchemaResponse() },
+		"/tfplugin6.Provider/ReadResource": func(req []byte) message {
+			var state cty.Value
+			_ = decodeFields(req, func(num protowire.Number, value []byte, _ uint64) error {
+				var err error
+				if num == 2 {
+					state, err = decodeDynamicValue(value, ty())
+				}
+				return err
+			})
+			attributes := state.AsValueMap()
+			attributes["name"] = cty.StringVal("read-" + attributes["id"].AsString())
+			newState, _ := encodeDynamicValue(cty.ObjectVal(attributes), ty())
+			return message{}.message(1, newState).bytes(3, []byte("private"))
+		},
+		"/tfplugin6.Provider/ImportResourceState": func(req []byte) message {
+			state, _ := encodeDynamicValue(cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.StringVal("imported"),
+				"name":  cty.NullVal(cty.String),
+				"tags":  cty.NullVal(cty.Map(cty.String)),
+				"ports": cty.NullVal(cty.Set(cty.Object(map[string]cty.Type{"from": cty.Number, "to": cty.Number}))),
+				"rule":  cty.ListValEmpty(cty.Object(map[string]cty.Type{"action": cty.String})),
+			}), ty())
+			path := message{}.message(1, message{}.string(1, "name"))
+			diagnostic := message{}.varint(1, 2).string(2, "name is deprecated").message(4, path)
+			return message{}.message(1, message{}.string(1, "test_resource").message(2, state)).message(2, diagnostic)
+		},
+	})
+
+	imported := p.ImportResourceState(providers.ImportResourceStateRequest{TypeName: "test_resource", ID: "imported"})
+	if imported.Diagnostics.HasErrors() || len(imported.ImportedResources) != 1 {
+		t.Fatalf("import failed: %v", imported.Diagnostics.Err())
+	}
+	if len(imported.Diagnostics) != 1 || imported.Diagnostics[0].Severity() != tfdiags.Warning {
+		t.Fatalf("expected a warning, got %v", imported.Diagnostics)
+	}
+	if path := tfdiags.GetAttribute(imported.Diagnostics[0]); len(path) != 1 || path[0].(cty.GetAttrStep).Name != "name" {
+		t.Errorf("wrong diagnostic path %#v", path)
+	}
+
+	read := p.ReadResource(providers.ReadResourceRequest{TypeName: "test_resource", PriorState: imported.ImportedResources[0].State})
+	if read.Diagnostics.HasErrors() {
+		t.Fatal(read.Diagnostics.Err())
+	}
+	if name := read.NewState.GetAttr("name").AsString(); name != "read-imported" {
+		t.Errorf("name %s, expected read-imported", name)
+	}
+	if string(read.Private) != "private" {
+		t.Errorf("private %q, expected private", read.Private)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerwrapper //nolint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	grpcproto "google.golang.org/grpc/encoding/proto"
+)
+
+// SchemaCacheDirEnv overrides the directory of cached provider schemas, "off" disables the cache
+const SchemaCacheDirEnv = "TERRAFORMER_SCHEMA_CACHE_DIR"
+
+// schemaMethods are the GetSchema methods of protocol v5 and v6, their responses have the same fields
+var schemaMethods = map[string]bool{
+	"/tfplugin5.Provider/GetSchema":         true,
+	"/tfplugin6.Provider/GetProviderSchema": true,
+}
+
+type binaryHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+var (
+	binaryHashesMu sync.Mutex
+	// plugin binaries have hundreds of MB, they are hashed once per process
+	binaryHashes = map[string]binaryHash{}
+)
+
+func schemaCacheDir() string {
+	dir := os.Getenv(SchemaCacheDirEnv)
+	if dir != "" {
+		if dir == "off" {
+			return ""
+		}
+		return dir
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "terraformer", "schemas")
+}
+
+func hashBinary(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	binaryHashesMu.Lock()
+	defer binaryHashesMu.Unlock()
+	if h, exist := binaryHashes[path]; exist && h.size == info.Size() && h.modTime.Equal(info.ModTime()) {
+		return h.hash, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	h := binaryHash{size: info.Size(), modTime: info.ModTime(), hash: hex.EncodeToString(hash.Sum(nil))}
+	binaryHashes[path] = h
+	return h.hash, nil
+}
+
+// schemaCachePath returns the cache file of the plugin binary, keyed by its version and hash,
+// or empty when the cache is disabled
+func schemaCachePath(providerName, providerFilePath string) string {
+	dir := schemaCacheDir()
+	if dir == "" {
+		return ""
+	}
+	hash, err := hashBinary(providerFilePath)
+	if err != nil {
+		log.Println("Can't hash provider plugin, schema cache disabled:", err)
+		return ""
+	}
+	version := "unknown"
+	if address, err := providerAddressFromPath(providerName, providerFilePath); err == nil && address.Version != "" {
+		version = address.Version
+	}
+	return filepath.Join(dir, providerName+"_"+version+"_"+hash+".pb")
+}
+
+var (
+	schemaCacheMu sync.Mutex
+	// schemaCacheData are the validated responses by cache path, every plugin instance of the
+	// pool asks for the schema and the files of large providers have several MB
+	schemaCacheData = map[string][]byte{}
+)
+
+// readSchemaCache returns the encoded GetSchema response cached at path, the file is only read once
+func readSchemaCache(path string) ([]byte, bool) {
+	schemaCacheMu.Lock()
+	defer schemaCacheMu.Unlock()
+	if data, exist := schemaCacheData[path]; exist {
+		return data, true
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	if _, err := decodeProviderSchemas(data); err != nil {
+		log.Println("Ignoring invalid schema cache", path)
+		return nil, false
+	}
+	schemaCacheData[path] = data
+	return data, true
+}
+
+// writeSchemaCache writes to a temporary file first, so concurrent readers never see partial schemas
+func writeSchemaCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// encodeReply and decodeReply handle the raw responses of the v6 client and the
+// generated messages of the vendored v5 client
+func encodeReply(reply interface{}) ([]byte, error) {
+	if raw, ok := reply.(*[]byte); ok {
+		return *raw, nil
+	}
+	return encoding.GetCodec(grpcproto.Name).Marshal(reply)
+}
+
+func decodeReply(data []byte, reply interface{}) error {
+	if raw, ok := reply.(*[]byte); ok {
+		*raw = append([]byte(nil), data...)
+		return nil
+	}
+	return encoding.GetCodec(grpcproto.Name).Unmarshal(data, reply)
+}
+
+// schemaCacheInterceptor answers GetSchema calls from the cache file at path, or fills it
+// with the response of the plugin. It wraps the connection, so the plugin clients are unchanged.
+func schemaCacheInterceptor(path string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !schemaMethods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if data, ok := readSchemaCache(path); ok {
+			if err := decodeReply(data, reply); err == nil {
+				return nil
+			}
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		data, err := encodeReply(reply)
+		if err != nil {
+			log.Println("Can't encode schema for the cache:", err)
+			return nil
+		}
+		if schema, err := decodeProviderSchemas(data); err != nil || schema.Diagnostics.HasErrors() {
+			return nil
+		}
+		schemaCacheMu.Lock()
+		schemaCacheData[path] = append([]byte(nil), data...)
+		schemaCacheMu.Unlock()
+		if err := writeSchemaCache(path, data); err != nil {
+			log.Println("Can't write schema cache:", err)
+		}
+		return nil
+	}
+}
+
+// schemaCacheDialOptions installs the schema cache of the plugin binary on the plugin connection
+func schemaCacheDialOptions(providerName, providerFilePath string) []grpc.DialOption {
+	path := schemaCachePath(providerName, providerFilePath)
+	if path == "" {
+		return nil
+	}
+	return []grpc.DialOption{grpc.WithUnaryInterceptor(schemaCacheInterceptor(path))}
+}
@@ -0,0 +1,140 @@
+package providerwrapper //nolint
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfplugin "github.com/hashicorp/terraform/plugin"
+	"github.com/hashicorp/terraform/providers"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// testV5SchemaResponse has no nested attributes, which protocol v5 doesn't know
+func testV5SchemaResponse() message {
+	attribute := func(name, ty string, flag protowire.Number) message {
+		return message{}.string(1, name).bytes(2, []byte(ty)).varint(flag, 1)
+	}
+	block := message{}.
+		message(2, attribute("id", `"string"`, 6)).
+		message(2, attribute("name", `"string"`, 4))
+	providerSchema := message{}.message(2, message{}.message(2, attribute("region", `"string"`, 5)))
+	return message{}.
+		message(1, providerSchema).
+		message(2, message{}.string(1, "test_resource").message(2, message{}.varint(1, 2).message(2, block)))
+}
+
+func testSchemaBinary(t *testing.T) string {
+	t.Setenv(SchemaCacheDirEnv, t.TempDir())
+	binary := filepath.Join(t.TempDir(), "terraform-provider-test_v1.2.3")
+	if err := ioutil.WriteFile(binary, []byte("plugin"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	return binary
+}
+
+// startV5Client serves tfplugin5 methods to the vendored protocol v5 client of Terraform
+func startV5Client(t *testing.T, handlers map[string]func(req []byte) message, opts ...grpc.DialOption) providers.Interface {
+	conn := startServer(t, handlers, opts...)
+	raw, err := (&tfplugin.GRPCProviderPlugin{}).GRPCClient(context.Background(), nil, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw.(providers.Interface)
+}
+
+func checkV5Schema(t *testing.T, resp providers.GetSchemaResponse) {
+	t.Helper()
+	if resp.Diagnostics.HasErrors() {
+		t.Fatal(resp.Diagnostics.Err())
+	}
+	schema := resp.ResourceTypes["test_resource"]
+	if schema.Version != 2 || schema.Block == nil || !schema.Block.Attributes["id"].Computed || !schema.Block.Attributes["name"].Required {
+		t.Errorf("wrong resource schema %v", schema)
+	}
+	if _, exist := resp.Provider.Block.Attributes["region"]; !exist {
+		t.Error("missing provider attribute region")
+	}
+}
+
+func TestSchemaCacheV5(t *testing.T) {
+	binary := testSchemaBinary(t)
+	fetched := startV5Client(t, map[string]func([]byte) message{
+		"/tfplugin5.Provider/GetSchema": func([]byte) message { return testV5SchemaResponse() },
+	}, schemaCacheDialOptions("test", binary)...)
+	checkV5Schema(t, fetched.GetSchema())
+	path := schemaCachePath("test", binary)
+	if filepath.Base(path)[:11] != "test_1.2.3_" {
+		t.Errorf("cache file %s isn't keyed by version", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("schema wasn't cached: %v", err)
+	}
+
+	// without GetSchema handler the server fails the test when the schema is fetched again
+	cached := startV5Client(t, map[string]func([]byte) message{}, schemaCacheDialOptions("test", binary)...)
+	checkV5Schema(t, cached.GetSchema())
+
+	if err := ioutil.WriteFile(binary, []byte("new plugin"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if schemaCachePath("test", binary) == path {
+		t.Error("changed binary uses the same cache file")
+	}
+}
+
+func TestSchemaCacheV6(t *testing.T) {
+	binary := testSchemaBinary(t)
+	fetched := startV6Server(t, map[string]func([]byte) message{
+		"/tfplugin6.Provider/GetProviderSchema": func([]byte) message { return testSchemaResponse() },
+	}, schemaCacheDialOptions("test", binary)...)
+	if resp := fetched.GetSchema(); resp.Diagnostics.HasErrors() {
+		t.Fatal(resp.Diagnostics.Err())
+	}
+
+	cached := startV6Server(t, map[string]func([]byte) message{}, schemaCacheDialOptions("test", binary)...)
+	resp := cached.GetSchema()
+	if resp.Diagnostics.HasErrors() || resp.ResourceTypes["test_resource"].Block.Attributes["ports"] == nil {
+		t.Errorf("cached schema differs: %v", resp)
+	}
+}
+
+func TestSchemaCacheSkipsErrors(t *testing.T) {
+	binary := testSchemaBinary(t)
+	failed := startV6Server(t, map[string]func([]byte) message{
+		"/tfplugin6.Provider/GetProviderSchema": func([]byte) message {
+			return message{}.message(4, message{}.varint(1, 1).string(2, "broken plugin"))
+		},
+	}, schemaCacheDialOptions("test", binary)...)
+	if resp := failed.GetSchema(); !resp.Diagnostics.HasErrors() {
+		t.Fatal("expected the error of the plugin")
+	}
+	if _, err := os.Stat(schemaCachePath("test", binary)); !os.IsNotExist(err) {
+		t.Error("failed schema responses must not be cached")
+	}
+}
+
+func TestSchemaCacheReadOnce(t *testing.T) {
+	binary := testSchemaBinary(t)
+	path := schemaCachePath("test", binary)
+	if err := writeSchemaCache(path, testSchemaResponse()); err != nil {
+		t.Fatal(err)
+	}
+	first := startV6Server(t, map[string]func([]byte) message{}, schemaCacheDialOptions("test", binary)...)
+	if resp := first.GetSchema(); resp.Diagnostics.HasErrors() {
+		t.Fatal(resp.Diagnostics.Err())
+	}
+
+	// further plugin instances get the schema from memory
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	second := startV6Server(t, map[string]func([]byte) message{}, schemaCacheDialOptions("test", binary)...)
+	resp := second.GetSchema()
+	if resp.Diagnostics.HasErrors() || resp.ResourceTypes["test_resource"].Block.Attributes["ports"] == nil {
+		t.Errorf("schema wasn't kept in memory: %v", resp)
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerwrapper //nolint
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"github.com/zclconf/go-cty/cty/msgpack"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The vendored Terraform only ships tfplugin5 stubs, so the messages of tfplugin6.proto
+// used by terraformer are encoded and decoded here with their field numbers.
+
+// rawCodec passes encoded messages through gRPC unchanged
+type rawCodec struct{}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case message:
+		return m, nil
+	case *[]byte:
+		return *m, nil
+	}
+	return nil, fmt.Errorf("tfplugin6: can't marshal %T", v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("tfplugin6: can't unmarshal into %T", v)
+	}
+	*b = append([]byte(nil), data...)
+	return nil
+}
+
+// message is an encoded protobuf message
+type message []byte
+
+func (m message) string(num protowire.Number, s string) message {
+	if s == "" {
+		return m
+	}
+	m = protowire.AppendTag(m, num, protowire.BytesType)
+	return protowire.AppendString(m, s)
+}
+
+func (m message) bytes(num protowire.Number, b []byte) message {
+	if len(b) == 0 {
+		return m
+	}
+	m = protowire.AppendTag(m, num, protowire.BytesType)
+	return protowire.AppendBytes(m, b)
+}
+
+func (m message) message(num protowire.Number, sub message) message {
+	m = protowire.AppendTag(m, num, protowire.BytesType)
+	return protowire.AppendBytes(m, sub)
+}
+
+func (m message) varint(num protowire.Number, v uint64) message {
+	if v == 0 {
+		return m
+	}
+	m = protowire.AppendTag(m, num, protowire.VarintType)
+	return protowire.AppendVarint(m, v)
+}
+
+// decodeFields calls field for every field of b, with value set for
+// length-delimited fields and number for varints
+func decodeFields(b []byte, field func(num protowire.Number, value []byte, number uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var err error
+		switch typ {
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			if n >= 0 {
+				err = field(num, nil, v)
+			}
+		case protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				err = field(num, v, 0)
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// encodeDynamicValue encodes a DynamicValue with msgpack like Terraform does
+func encodeDynamicValue(v cty.Value, ty cty.Type) (message, error) {
+	mp, err := msgpack.Marshal(v, ty)
+	if err != nil {
+		return nil, err
+	}
+	return message{}.bytes(1, mp), nil
+}
+
+func decodeDynamicValue(b []byte, ty cty.Type) (cty.Value, error) {
+	var mp, js []byte
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		switch num {
+		case 1:
+			mp = value
+		case 2:
+			js = value
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		return cty.NilVal, err
+	case len(mp) > 0:
+		return msgpack.Unmarshal(mp, ty)
+	case len(js) > 0:
+		return ctyjson.Unmarshal(js, ty)
+	}
+	return cty.NullVal(ty), nil
+}
+
+func decodeDiagnostic(b []byte) (tfdiags.Diagnostic, error) {
+	severity := tfdiags.Error
+	var summary, detail string
+	var path cty.Path
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		var err error
+		switch num {
+		case 1:
+			if number == 2 {
+				severity = tfdiags.Warning
+			}
+		case 2:
+			summary = string(value)
+		case 3:
+			detail = string(value)
+		case 4:
+			path, err = decodeAttributePath(value)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if path != nil {
+		return tfdiags.AttributeValue(severity, summary, detail, path), nil
+	}
+	return tfdiags.Sourceless(severity, summary, detail), nil
+}
+
+func decodeAttributePath(b []byte) (cty.Path, error) {
+	var path cty.Path
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		return decodeFields(value, func(num protowire.Number, value []byte, number uint64) error {
+			switch num {
+			case 1:
+				path = append(path, cty.GetAttrStep{Name: string(value)})
+			case 2:
+				path = append(path, cty.IndexStep{Key: cty.StringVal(string(value))})
+			case 3:
+				path = append(path, cty.IndexStep{Key: cty.NumberIntVal(int64(number))})
+			}
+			return nil
+		})
+	})
+	return path, err
+}
+
+// diagnosticsField returns a field decoder appending diagnostics of field number num
+func diagnosticsField(diags *tfdiags.Diagnostics, num protowire.Number) func(protowire.Number, []byte) error {
+	return func(n protowire.Number, value []byte) error {
+		if n != num {
+			return nil
+		}
+		diag, err := decodeDiagnostic(value)
+		if err != nil {
+			return err
+		}
+		*diags = diags.Append(diag)
+		return nil
+	}
+}
+
+func decodeProviderSchemas(b []byte) (providers.GetSchemaResponse, error) {
+	resp := providers.GetSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{},
+		DataSources:   map[string]providers.Schema{},
+	}
+	diagnostics := diagnosticsField(&resp.Diagnostics, 4)
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		switch num {
+		case 1:
+			schema, err := decodeSchema(value)
+			resp.Provider = schema
+			return err
+		case 2, 3:
+			name, schema, err := decodeSchemaEntry(value)
+			if num == 2 {
+				resp.ResourceTypes[name] = schema
+			} else {
+				resp.DataSources[name] = schema
+			}
+			return err
+		}
+		return diagnostics(num, value)
+	})
+	if err == nil && resp.Provider.Block == nil && !resp.Diagnostics.HasErrors() {
+		err = errors.New("missing provider schema")
+	}
+	return resp, err
+}
+
+func decodeSchemaEntry(b []byte) (string, providers.Schema, error) {
+	var name string
+	var schema providers.Schema
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(value)
+		case 2:
+			schema, err = decodeSchema(value)
+		}
+		return err
+	})
+	return name, schema, err
+}
+
+func decodeSchema(b []byte) (providers.Schema, error) {
+	schema := providers.Schema{Block: &configschema.Block{}}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		var err error
+		switch num {
+		case 1:
+			schema.Version = int64(number)
+		case 2:
+			schema.Block, err = decodeBlock(value)
+		}
+		return err
+	})
+	return schema, err
+}
+
+func decodeBlock(b []byte) (*configschema.Block, error) {
+	block := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{},
+		BlockTypes: map[string]*configschema.NestedBlock{},
+	}
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		switch num {
+		case 2:
+			name, attribute, err := decodeAttribute(value)
+			block.Attributes[name] = attribute
+			return err
+		case 3:
+			name, nested, err := decodeNestedBlock(value)
+			block.BlockTypes[name] = nested
+			return err
+		}
+		return nil
+	})
+	return block, err
+}
+
+func decodeAttribute(b []byte) (string, *configschema.Attribute, error) {
+	var name string
+	attribute := &configschema.Attribute{}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(value)
+		case 2:
+			err = attribute.Type.UnmarshalJSON(value)
+		case 3:
+			attribute.Description = string(value)
+		case 4:
+			attribute.Required = number != 0
+		case 5:
+			attribute.Optional = number != 0
+		case 6:
+			attribute.Computed = number != 0
+		case 7:
+			attribute.Sensitive = number != 0
+		case 10:
+			// nested attributes of the plugin framework are seen as their implied type
+			attribute.Type, err = decodeNestedType(value)
+		}
+		return err
+	})
+	return name, attribute, err
+}
+
+func decodeNestedType(b []byte) (cty.Type, error) {
+	attributes := map[string]cty.Type{}
+	var nesting uint64
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		switch num {
+		case 1:
+			name, attribute, err := decodeAttribute(value)
+			attributes[name] = attribute.Type
+			return err
+		case 3:
+			nesting = number
+		}
+		return nil
+	})
+	object := cty.Object(attributes)
+	switch nesting {
+	case 2:
+		return cty.List(object), err
+	case 3:
+		return cty.Set(object), err
+	case 4:
+		return cty.Map(object), err
+	}
+	return object, err
+}
+
+// tfplugin6 nesting modes: SINGLE, LIST, SET, MAP, GROUP
+var nestingModes = map[uint64]configschema.NestingMode{
+	1: configschema.NestingSingle,
+	2: configschema.NestingList,
+	3: configschema.NestingSet,
+	4: configschema.NestingMap,
+	5: configschema.NestingGroup,
+}
+
+func decodeNestedBlock(b []byte) (string, *configschema.NestedBlock, error) {
+	var name string
+	nested := &configschema.NestedBlock{}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		switch num {
+		case 1:
+			name = string(value)
+		case 2:
+			block, err := decodeBlock(value)
+			if err != nil {
+				return err
+			}
+			nested.Block = *block
+		case 3:
+			nested.Nesting = nestingModes[number]
+		case 4:
+			nested.MinItems = int(number)
+		case 5:
+			nested.MaxItems = int(number)
+		}
+		return nil
+	})
+	return name, nested, err
+}
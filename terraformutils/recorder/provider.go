@@ -0,0 +1,177 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// recordingProvider keeps the schemas and states of resources read from the plugin
+type recordingProvider struct {
+	providers.Interface
+	recorder *Recorder
+}
+
+// Provider wraps the provider plugin: in record mode reads are recorded,
+// in replay mode the plugin isn't used at all and may be nil.
+func (r *Recorder) Provider(plugin providers.Interface) providers.Interface {
+	if r.mode == Replay {
+		return &replayProvider{recorder: r}
+	}
+	return &recordingProvider{Interface: plugin, recorder: r}
+}
+
+func (p *recordingProvider) ReadResource(req providers.ReadResourceRequest) providers.ReadResourceResponse {
+	resp := p.Interface.ReadResource(req)
+	if !resp.Diagnostics.HasErrors() && !resp.NewState.IsNull() {
+		p.recorder.recordState(p.Interface, req.TypeName, resourceID(req.PriorState), resp.NewState)
+	}
+	return resp
+}
+
+func (p *recordingProvider) ImportResourceState(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	resp := p.Interface.ImportResourceState(req)
+	if !resp.Diagnostics.HasErrors() && len(resp.ImportedResources) > 0 {
+		p.recorder.recordState(p.Interface, req.TypeName, req.ID, resp.ImportedResources[0].State)
+	}
+	return resp
+}
+
+func (r *Recorder) recordState(plugin providers.Interface, resourceType, id string, state cty.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schema, exist := r.cassette.Schemas[resourceType]
+	if !exist {
+		schema, exist = plugin.GetSchema().ResourceTypes[resourceType]
+		if !exist {
+			return
+		}
+		r.cassette.Schemas[resourceType] = schema
+	}
+	data, err := ctyjson.Marshal(state, schema.Block.ImpliedType())
+	if err != nil {
+		return
+	}
+	r.cassette.Resources = append(r.cassette.Resources, ResourceRead{Type: resourceType, ID: id, State: data})
+}
+
+func (r *Recorder) readState(resourceType, id string) (cty.Value, error) {
+	schema, exist := r.cassette.Schemas[resourceType]
+	if !exist {
+		return cty.NilVal, fmt.Errorf("recorder: no recorded schema for %s", resourceType)
+	}
+	for _, read := range r.cassette.Resources {
+		if read.Type == resourceType && read.ID == id {
+			return ctyjson.Unmarshal(read.State, schema.Block.ImpliedType())
+		}
+	}
+	return cty.NilVal, fmt.Errorf("recorder: no recorded state for %s %s", resourceType, id)
+}
+
+func resourceID(state cty.Value) string {
+	if state.IsNull() || !state.IsKnown() || !state.Type().IsObjectType() || !state.Type().HasAttribute("id") {
+		return ""
+	}
+	id := state.GetAttr("id")
+	if id.IsNull() || !id.IsKnown() || id.Type() != cty.String {
+		return ""
+	}
+	return id.AsString()
+}
+
+// replayProvider answers reads from the cassette. Validation, plans and
+// data sources aren't recorded, so they are reported as unsupported.
+type replayProvider struct {
+	recorder *Recorder
+}
+
+func unsupported(method string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	return diags.Append(fmt.Errorf("recorder: %s is not supported when replaying", method))
+}
+
+func (p *replayProvider) GetSchema() providers.GetSchemaResponse {
+	return providers.GetSchemaResponse{
+		Provider:      providers.Schema{Block: &configschema.Block{}},
+		ResourceTypes: p.recorder.cassette.Schemas,
+		DataSources:   map[string]providers.Schema{},
+	}
+}
+
+func (p *replayProvider) PrepareProviderConfig(req providers.PrepareProviderConfigRequest) providers.PrepareProviderConfigResponse {
+	return providers.PrepareProviderConfigResponse{PreparedConfig: req.Config}
+}
+
+func (p *replayProvider) ValidateResourceTypeConfig(providers.ValidateResourceTypeConfigRequest) providers.ValidateResourceTypeConfigResponse {
+	return providers.ValidateResourceTypeConfigResponse{Diagnostics: unsupported("ValidateResourceTypeConfig")}
+}
+
+func (p *replayProvider) ValidateDataSourceConfig(providers.ValidateDataSourceConfigRequest) providers.ValidateDataSourceConfigResponse {
+	return providers.ValidateDataSourceConfigResponse{Diagnostics: unsupported("ValidateDataSourceConfig")}
+}
+
+func (p *replayProvider) UpgradeResourceState(providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+	return providers.UpgradeResourceStateResponse{Diagnostics: unsupported("UpgradeResourceState")}
+}
+
+func (p *replayProvider) Configure(providers.ConfigureRequest) providers.ConfigureResponse {
+	return providers.ConfigureResponse{}
+}
+
+func (p *replayProvider) Stop() error {
+	return nil
+}
+
+func (p *replayProvider) ReadResource(req providers.ReadResourceRequest) providers.ReadResourceResponse {
+	state, err := p.recorder.readState(req.TypeName, resourceID(req.PriorState))
+	if err != nil {
+		var diags tfdiags.Diagnostics
+		return providers.ReadResourceResponse{Diagnostics: diags.Append(err)}
+	}
+	return providers.ReadResourceResponse{NewState: state, Private: req.Private}
+}
+
+func (p *replayProvider) PlanResourceChange(providers.PlanResourceChangeRequest) providers.PlanResourceChangeResponse {
+	return providers.PlanResourceChangeResponse{Diagnostics: unsupported("PlanResourceChange")}
+}
+
+func (p *replayProvider) ApplyResourceChange(providers.ApplyResourceChangeRequest) providers.ApplyResourceChangeResponse {
+	return providers.ApplyResourceChangeResponse{Diagnostics: unsupported("ApplyResourceChange")}
+}
+
+func (p *replayProvider) ImportResourceState(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	state, err := p.recorder.readState(req.TypeName, req.ID)
+	if err != nil {
+		var diags tfdiags.Diagnostics
+		return providers.ImportResourceStateResponse{Diagnostics: diags.Append(err)}
+	}
+	return providers.ImportResourceStateResponse{
+		ImportedResources: []providers.ImportedResource{{TypeName: req.TypeName, State: state}},
+	}
+}
+
+func (p *replayProvider) ReadDataSource(providers.ReadDataSourceRequest) providers.ReadDataSourceResponse {
+	return providers.ReadDataSourceResponse{Diagnostics: unsupported("ReadDataSource")}
+}
+
+func (p *replayProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recorder records the HTTP traffic of service generators and the
+// resources read by provider plugins into cassettes, and replays them offline.
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hashicorp/terraform/providers"
+)
+
+type Mode int
+
+const (
+	// Record sends requests to the cloud and the provider plugin and keeps the responses
+	Record Mode = iota
+	// Replay answers requests from the cassette, nothing leaves the process
+	Replay
+)
+
+// Interaction is one HTTP request of a service generator and its response
+type Interaction struct {
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Body     string            `json:"body,omitempty"`
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Response string            `json:"response"`
+}
+
+// ResourceRead is the state returned by the provider plugin for a resource
+type ResourceRead struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id"`
+	State json.RawMessage `json:"state"`
+}
+
+// Cassette holds everything needed to import resources without network and plugin
+type Cassette struct {
+	Provider        string                      `json:"provider"`
+	ProviderSource  string                      `json:"provider_source,omitempty"`
+	ProviderVersion string                      `json:"provider_version,omitempty"`
+	Interactions    []Interaction               `json:"interactions"`
+	Schemas         map[string]providers.Schema `json:"schemas"`
+	Resources       []ResourceRead              `json:"resources"`
+}
+
+// Recorder fills or replays a cassette
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	mode     Mode
+	cassette *Cassette
+	replayed map[int]bool
+}
+
+var (
+	activeMu sync.Mutex
+	active   *Recorder
+)
+
+// Start makes a recorder active for the process. In replay mode the cassette has to exist.
+func Start(path string, mode Mode) (*Recorder, error) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if active != nil {
+		return nil, errors.New("recorder: another cassette is active: " + active.path)
+	}
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		cassette: &Cassette{Schemas: map[string]providers.Schema{}},
+		replayed: map[int]bool{},
+	}
+	if mode == Replay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, r.cassette); err != nil {
+			return nil, errors.New("recorder: invalid cassette " + path + ": " + err.Error())
+		}
+	}
+	active = r
+	return r, nil
+}
+
+// Active returns the active recorder or nil
+func Active() *Recorder {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return active
+}
+
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Stop deactivates the recorder and writes the cassette of a recording
+func (r *Recorder) Stop() error {
+	activeMu.Lock()
+	if active == r {
+		active = nil
+	}
+	activeMu.Unlock()
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), os.ModePerm)
+}
+
+// SetProvider keeps the name, source address and version of the recorded provider plugin
+func (r *Recorder) SetProvider(name, source, version string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Provider = name
+	r.cassette.ProviderSource = source
+	r.cassette.ProviderVersion = version
+}
+
+// ProviderAddress returns the source address and version of the recorded provider plugin
+func (r *Recorder) ProviderAddress() (source, version string) {
+	return r.cassette.ProviderSource, r.cassette.ProviderVersion
+}
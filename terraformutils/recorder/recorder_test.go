@@ -0,0 +1,108 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type fakePlugin struct {
+	providers.Interface
+}
+
+func (fakePlugin) GetSchema() providers.GetSchemaResponse {
+	return providers.GetSchemaResponse{ResourceTypes: map[string]providers.Schema{
+		"type1": {Block: &configschema.Block{Attributes: map[string]*configschema.Attribute{
+			"id":   {Type: cty.String, Computed: true},
+			"name": {Type: cty.String, Required: true},
+		}}},
+	}}
+}
+
+func (fakePlugin) ReadResource(req providers.ReadResourceRequest) providers.ReadResourceResponse {
+	return providers.ReadResourceResponse{NewState: cty.ObjectVal(map[string]cty.Value{
+		"id":   req.PriorState.GetAttr("id"),
+		"name": cty.StringVal("name-" + req.PriorState.GetAttr("id").AsString()),
+	})}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%d %s", resp.StatusCode, body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, "%s call %d", r.URL.Path, calls)
+	}))
+	defer server.Close()
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	prior := cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("a"), "name": cty.NullVal(cty.String)})
+
+	r, err := Start(cassette, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := HTTPClient()
+	recorded := []string{get(t, client, server.URL+"/page"), get(t, client, server.URL+"/page")}
+	r.Provider(fakePlugin{}).ReadResource(providers.ReadResourceRequest{TypeName: "type1", PriorState: prior})
+	if err := r.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = Start(cassette, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop() //nolint
+	replayed := []string{get(t, client, server.URL+"/page"), get(t, client, server.URL+"/page")}
+	if calls != 2 {
+		t.Errorf("replay sent requests, %d calls", calls)
+	}
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("response %d: recorded %q, replayed %q", i, recorded[i], replayed[i])
+		}
+	}
+	if _, err := client.Get(server.URL + "/page"); err == nil {
+		t.Error("expected error for request which isn't recorded")
+	}
+	resp := r.Provider(nil).ReadResource(providers.ReadResourceRequest{TypeName: "type1", PriorState: prior})
+	if resp.Diagnostics.HasErrors() {
+		t.Fatal(resp.Diagnostics.Err())
+	}
+	if name := resp.NewState.GetAttr("name").AsString(); name != "name-a" {
+		t.Errorf("replayed name %s, expected name-a", name)
+	}
+}
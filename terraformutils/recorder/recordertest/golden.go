@@ -0,0 +1,139 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recordertest replays cassettes in tests and compares the output with golden files
+package recordertest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/recorder"
+)
+
+const (
+	// RecordEnv records cassettes against real accounts instead of replaying them
+	RecordEnv = "TERRAFORMER_RECORD"
+	// UpdateGoldenEnv rewrites golden files with the generated output
+	UpdateGoldenEnv = "TERRAFORMER_UPDATE_GOLDEN"
+)
+
+// Lineage is random for every new state
+var lineageRe = regexp.MustCompile(`"lineage": "[^"]*"`)
+
+// Run imports with the cassette and compares the generated files with the golden directory.
+// With TERRAFORMER_RECORD set the cassette is recorded again and golden files are updated.
+func Run(t *testing.T, cassette, golden string, importResources func(output string) error) {
+	t.Helper()
+	mode := recorder.Replay
+	if os.Getenv(RecordEnv) != "" {
+		mode = recorder.Record
+	}
+	r, err := recorder.Start(cassette, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := t.TempDir()
+	err = importResources(output)
+	if stopErr := r.Stop(); stopErr != nil {
+		t.Fatal(stopErr)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := readOutput(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == recorder.Record || os.Getenv(UpdateGoldenEnv) != "" {
+		if err := writeGolden(golden, generated); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := readOutput(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range sortedNames(expected, generated) {
+		switch {
+		case expected[name] == "":
+			t.Errorf("unexpected file %s:\n%s", name, generated[name])
+		case generated[name] == "":
+			t.Errorf("missing file %s", name)
+		case expected[name] != generated[name]:
+			t.Errorf("%s differs\nexpected:\n%s\ngenerated:\n%s", name, expected[name], generated[name])
+		}
+	}
+}
+
+func readOutput(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content := string(data)
+		if strings.HasSuffix(name, ".tfstate") {
+			content = lineageRe.ReplaceAllString(content, `"lineage": ""`)
+		}
+		files[filepath.ToSlash(name)] = content
+		return nil
+	})
+	return files, err
+}
+
+func writeGolden(dir string, files map[string]string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedNames(files ...map[string]string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, f := range files {
+		for name := range f {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Response headers which are never written to cassettes
+var skippedHeaders = map[string]bool{
+	"Set-Cookie": true,
+	"Date":       true,
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport wraps base so that requests go through the recorder active at the time
+// of the request. Without active recorder requests are sent with base.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// HTTPClient returns a client sending requests through the active recorder
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: Transport(http.DefaultTransport)}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := Active()
+	if r == nil {
+		return t.base.RoundTrip(req)
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if r.mode == Replay {
+		return r.replay(req, string(body))
+	}
+	return r.record(t.base, req, string(body))
+}
+
+func (r *Recorder) record(base http.RoundTripper, req *http.Request, body string) (*http.Response, error) {
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	interaction := Interaction{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Body:     body,
+		Status:   resp.StatusCode,
+		Headers:  map[string]string{},
+		Response: string(data),
+	}
+	for k := range resp.Header {
+		if !skippedHeaders[k] {
+			interaction.Headers[k] = resp.Header.Get(k)
+		}
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay answers with the first interaction for the same request which isn't replayed yet,
+// so paginated and repeated requests are answered in the recorded order
+func (r *Recorder) replay(req *http.Request, body string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || interaction.Method != req.Method || interaction.URL != req.URL.String() || interaction.Body != body {
+			continue
+		}
+		r.replayed[i] = true
+		resp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{},
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response)),
+			ContentLength: int64(len(interaction.Response)),
+			Request:       req,
+		}
+		for k, v := range interaction.Headers {
+			resp.Header.Set(k, v)
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("recorder: no recorded response for %s %s %s", req.Method, req.URL, body)
+}
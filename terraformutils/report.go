@@ -0,0 +1,75 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+// ImportReport lists what needs attention after an import, indexed by resource address
+type ImportReport struct {
+	DroppedAttributes map[string][]string `json:"dropped_attributes,omitempty"`
+	ValidationErrors  map[string][]string `json:"validation_errors,omitempty"`
+	IgnoredChanges    map[string][]string `json:"ignored_changes,omitempty"`
+	// Excluded are resources which were found but not imported, with the reasons
+	Excluded map[string][]string `json:"excluded,omitempty"`
+}
+
+func NewImportReport() *ImportReport {
+	return &ImportReport{
+		DroppedAttributes: map[string][]string{},
+		ValidationErrors:  map[string][]string{},
+		IgnoredChanges:    map[string][]string{},
+		Excluded:          map[string][]string{},
+	}
+}
+
+func (r *ImportReport) AddValidation(address string, dropped, errs []string) {
+	if len(dropped) > 0 {
+		r.DroppedAttributes[address] = append(r.DroppedAttributes[address], dropped...)
+	}
+	if len(errs) > 0 {
+		r.ValidationErrors[address] = append(r.ValidationErrors[address], errs...)
+	}
+}
+
+func (r *ImportReport) AddIgnoredChanges(address string, attributes []string) {
+	if len(attributes) > 0 {
+		r.IgnoredChanges[address] = append(r.IgnoredChanges[address], attributes...)
+	}
+}
+
+func (r *ImportReport) AddExcluded(address string, reason string) {
+	r.Excluded[address] = append(r.Excluded[address], reason)
+}
+
+func (r *ImportReport) IsEmpty() bool {
+	return len(r.DroppedAttributes) == 0 && len(r.ValidationErrors) == 0 && len(r.IgnoredChanges) == 0 && len(r.Excluded) == 0
+}
+
+// Merge adds the entries of other, with addresses changed by address
+func (r *ImportReport) Merge(other *ImportReport, address func(string) string) {
+	if other == nil {
+		return
+	}
+	for k, v := range other.DroppedAttributes {
+		r.DroppedAttributes[address(k)] = append(r.DroppedAttributes[address(k)], v...)
+	}
+	for k, v := range other.ValidationErrors {
+		r.ValidationErrors[address(k)] = append(r.ValidationErrors[address(k)], v...)
+	}
+	for k, v := range other.IgnoredChanges {
+		r.IgnoredChanges[address(k)] = append(r.IgnoredChanges[address(k)], v...)
+	}
+	for k, v := range other.Excluded {
+		r.Excluded[address(k)] = append(r.Excluded[address(k)], v...)
+	}
+}
@@ -37,7 +37,11 @@ type Resource struct {
 	AllowEmptyValues  []string               `json:",omitempty"`
 	AdditionalFields  map[string]interface{} `json:",omitempty"`
 	SlowQueryRequired bool
+	ImportRequired    bool `json:",omitempty"` // imported by ID before refresh, like terraform import
 	DataFiles         map[string][]byte
+	DataSource        bool   `json:",omitempty"` // written as data block without state
+	ProviderAlias     string `json:",omitempty"` // alias of the provider block the resource uses
+	ManagedBy         string `json:",omitempty"` // controller owning the resource, set by generators which know it
 }
 
 type ApplicableFilter interface {
@@ -49,9 +53,13 @@ type ResourceFilter struct {
 	ServiceName      string
 	FieldPath        string
 	AcceptableValues []string
+	Expression       *FilterExpression // set for filter expressions, see ParseFilterExpression
 }
 
 func (rf *ResourceFilter) Filter(resource Resource) bool {
+	if rf.Expression != nil {
+		return rf.Expression.Match(resource)
+	}
 	if !rf.IsApplicable(strings.TrimPrefix(resource.InstanceInfo.Type, resource.Provider+"_")) {
 		return true
 	}
@@ -86,6 +94,9 @@ func (rf *ResourceFilter) IsApplicable(serviceName string) bool {
 }
 
 func (rf *ResourceFilter) isInitial() bool {
+	if rf.Expression != nil {
+		return rf.Expression.isInitial()
+	}
 	return rf.FieldPath == "id"
 }
 
@@ -127,7 +138,11 @@ func (r *Resource) Refresh(provider *providerwrapper.ProviderWrapper) {
 	if r.SlowQueryRequired {
 		time.Sleep(200 * time.Millisecond)
 	}
-	r.InstanceState, err = provider.Refresh(r.InstanceInfo, r.InstanceState)
+	if r.ImportRequired {
+		r.InstanceState, err = provider.Import(r.InstanceInfo, r.InstanceState.ID)
+	} else {
+		r.InstanceState, err = provider.Refresh(r.InstanceInfo, r.InstanceState)
+	}
 	if err != nil {
 		log.Println(err)
 	}
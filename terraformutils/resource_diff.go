@@ -0,0 +1,211 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// AttributeDiff is a changed flat attribute, Old is nil for added and New for removed attributes
+type AttributeDiff struct {
+	Key string  `json:"key"`
+	Old *string `json:"old"`
+	New *string `json:"new"`
+}
+
+// ResourceChange is a resource found in only one of two imports, or with changed attributes
+type ResourceChange struct {
+	Service    string          `json:"service"`
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Alias      string          `json:"provider_alias,omitempty"`
+	Attributes []AttributeDiff `json:"attributes,omitempty"`
+}
+
+// ResourcesDiff compares resources of two imports by type and ID
+type ResourcesDiff struct {
+	Added    []ResourceChange `json:"added"`
+	Removed  []ResourceChange `json:"removed"`
+	Modified []ResourceChange `json:"modified"`
+}
+
+type diffedResource struct {
+	service  string
+	resource Resource
+}
+
+func diffKey(r Resource) string {
+	return r.ProviderAlias + "/" + r.InstanceInfo.Type + "/" + r.InstanceState.ID
+}
+
+func indexResources(resourcesByService map[string][]Resource) map[string]diffedResource {
+	index := map[string]diffedResource{}
+	for service, resources := range resourcesByService {
+		for _, r := range resources {
+			index[diffKey(r)] = diffedResource{service: service, resource: r}
+		}
+	}
+	return index
+}
+
+func newResourceChange(d diffedResource) ResourceChange {
+	return ResourceChange{
+		Service: d.service,
+		Type:    d.resource.InstanceInfo.Type,
+		ID:      d.resource.InstanceState.ID,
+		Name:    d.resource.ResourceName,
+		Alias:   d.resource.ProviderAlias,
+	}
+}
+
+// DiffResources compares the flat attributes of resources by service of two imports.
+// Attributes matching the ignore keys of either resource or ignoreKeys aren't compared.
+func DiffResources(oldResources, newResources map[string][]Resource, ignoreKeys []*regexp.Regexp) ResourcesDiff {
+	diff := ResourcesDiff{
+		Added:    []ResourceChange{},
+		Removed:  []ResourceChange{},
+		Modified: []ResourceChange{},
+	}
+	oldIndex := indexResources(oldResources)
+	newIndex := indexResources(newResources)
+	for key, n := range newIndex {
+		o, exist := oldIndex[key]
+		if !exist {
+			diff.Added = append(diff.Added, newResourceChange(n))
+			continue
+		}
+		keys := append([]*regexp.Regexp{}, ignoreKeys...)
+		for _, pattern := range append(o.resource.IgnoreKeys, n.resource.IgnoreKeys...) {
+			if re, err := regexp.Compile(pattern); err == nil {
+				keys = append(keys, re)
+			}
+		}
+		attributes := diffAttributes(o.resource.InstanceState.Attributes, n.resource.InstanceState.Attributes, keys)
+		if len(attributes) > 0 {
+			change := newResourceChange(n)
+			change.Attributes = attributes
+			diff.Modified = append(diff.Modified, change)
+		}
+	}
+	for key, o := range oldIndex {
+		if _, exist := newIndex[key]; !exist {
+			diff.Removed = append(diff.Removed, newResourceChange(o))
+		}
+	}
+	for _, changes := range [][]ResourceChange{diff.Added, diff.Removed, diff.Modified} {
+		sortResourceChanges(changes)
+	}
+	return diff
+}
+
+func sortResourceChanges(changes []ResourceChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Alias < b.Alias
+	})
+}
+
+func diffAttributes(oldAttributes, newAttributes map[string]string, ignoreKeys []*regexp.Regexp) []AttributeDiff {
+	var diffs []AttributeDiff
+	ignored := func(key string) bool {
+		for _, re := range ignoreKeys {
+			if re.MatchString(key) {
+				return true
+			}
+		}
+		return false
+	}
+	for key, newValue := range newAttributes {
+		if ignored(key) {
+			continue
+		}
+		newValue := newValue
+		oldValue, exist := oldAttributes[key]
+		if !exist {
+			diffs = append(diffs, AttributeDiff{Key: key, New: &newValue})
+		} else if oldValue != newValue {
+			diffs = append(diffs, AttributeDiff{Key: key, Old: &oldValue, New: &newValue})
+		}
+	}
+	for key, oldValue := range oldAttributes {
+		if ignored(key) {
+			continue
+		}
+		oldValue := oldValue
+		if _, exist := newAttributes[key]; !exist {
+			diffs = append(diffs, AttributeDiff{Key: key, Old: &oldValue})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+func (d ResourcesDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// WriteText writes the diff like a plan, + for added, - for removed and ~ for modified resources and attributes
+func (d ResourcesDiff) WriteText(w io.Writer) error {
+	address := func(c ResourceChange) string {
+		if c.Alias != "" {
+			return c.Type + " " + c.ID + " (" + c.Alias + ")"
+		}
+		return c.Type + " " + c.ID
+	}
+	for _, c := range d.Added {
+		if _, err := fmt.Fprintf(w, "+ %s\n", address(c)); err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Removed {
+		if _, err := fmt.Fprintf(w, "- %s\n", address(c)); err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Modified {
+		if _, err := fmt.Fprintf(w, "~ %s\n", address(c)); err != nil {
+			return err
+		}
+		for _, a := range c.Attributes {
+			var line string
+			switch {
+			case a.Old == nil:
+				line = fmt.Sprintf("    + %s = %s\n", a.Key, strconv.Quote(*a.New))
+			case a.New == nil:
+				line = fmt.Sprintf("    - %s = %s\n", a.Key, strconv.Quote(*a.Old))
+			default:
+				line = fmt.Sprintf("    ~ %s = %s => %s\n", a.Key, strconv.Quote(*a.Old), strconv.Quote(*a.New))
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d modified\n", len(d.Added), len(d.Removed), len(d.Modified))
+	return err
+}
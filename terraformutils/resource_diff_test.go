@@ -0,0 +1,69 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestDiffResources(t *testing.T) {
+	oldVpc := NewResource("vpc-1", "main", "aws_vpc", "aws", map[string]string{
+		"cidr_block": "10.0.0.0/16",
+		"tags.%":     "1",
+		"tags.team":  "network",
+		"arn":        "arn:aws:ec2:eu-west-1:123456789012:vpc/vpc-1",
+	}, []string{}, map[string]interface{}{})
+	oldVpc.IgnoreKeys = []string{"^arn$"}
+	newVpc := NewResource("vpc-1", "main", "aws_vpc", "aws", map[string]string{
+		"cidr_block":   "10.1.0.0/16",
+		"tags.%":       "1",
+		"tags.owner":   "sre",
+		"arn":          "changed",
+		"last_changed": "today",
+	}, []string{}, map[string]interface{}{})
+	oldSubnet := NewResource("subnet-1", "a", "aws_subnet", "aws", map[string]string{}, []string{}, map[string]interface{}{})
+	newSubnet := NewResource("subnet-2", "b", "aws_subnet", "aws", map[string]string{}, []string{}, map[string]interface{}{})
+
+	diff := DiffResources(
+		map[string][]Resource{"vpc": {oldVpc}, "subnet": {oldSubnet}},
+		map[string][]Resource{"vpc": {newVpc}, "subnet": {newSubnet}},
+		[]*regexp.Regexp{regexp.MustCompile("^last_changed$")},
+	)
+
+	var buf bytes.Buffer
+	if err := diff.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `+ aws_subnet subnet-2
+- aws_subnet subnet-1
+~ aws_vpc vpc-1
+    ~ cidr_block = "10.0.0.0/16" => "10.1.0.0/16"
+    + tags.owner = "sre"
+    - tags.team = "network"
+1 added, 1 removed, 1 modified
+`
+	if buf.String() != expected {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), expected)
+	}
+	if diff.Modified[0].Service != "vpc" || diff.Added[0].Name != "tfer--b" {
+		t.Errorf("unexpected changes %+v", diff)
+	}
+
+	if !DiffResources(map[string][]Resource{"vpc": {oldVpc}}, map[string][]Resource{"vpc": {oldVpc}}, nil).IsEmpty() {
+		t.Error("same resources have a diff")
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobRequest starts an import of a provider
+type JobRequest struct {
+	Provider string `json:"provider"`
+	// Options are the ImportOptions of the import, missing fields keep the defaults of the flags
+	Options json.RawMessage `json:"options,omitempty"`
+	// Args are flags of the provider command which aren't ImportOptions, like --api-key=value,
+	// the serve command only accepts credentials and the scope of the import
+	Args []string `json:"args,omitempty"`
+}
+
+// Job is an import run by the server, its files are written to Dir
+type Job struct {
+	ID       string         `json:"id"`
+	Request  JobRequest     `json:"request"`
+	Status   JobStatus      `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Progress map[string]int `json:"progress"` // resources by progress event
+	Created  time.Time      `json:"created"`
+	Started  *time.Time     `json:"started,omitempty"`
+	Finished *time.Time     `json:"finished,omitempty"`
+	Dir      string         `json:"-"`
+
+	mu      sync.Mutex
+	logs    []string
+	partial []byte
+	changed chan struct{} // closed and replaced when logs or status change
+	metrics *Metrics
+}
+
+func newJob(id string, request JobRequest, dir string, metrics *Metrics) *Job {
+	return &Job{
+		ID:       id,
+		Request:  request,
+		Status:   JobQueued,
+		Progress: map[string]int{},
+		Created:  time.Now(),
+		Dir:      dir,
+		changed:  make(chan struct{}),
+		metrics:  metrics,
+	}
+}
+
+// Write splits output of the import into log lines, progress events are counted instead of logged
+func (j *Job) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.partial = append(j.partial, p...)
+	for {
+		i := bytes.IndexByte(j.partial, '\n')
+		if i < 0 {
+			break
+		}
+		j.addLine(string(j.partial[:i]))
+		j.partial = j.partial[i+1:]
+	}
+	j.notify()
+	return len(p), nil
+}
+
+func (j *Job) addLine(line string) {
+	if event, ok := terraformutils.ParseProgress(line); ok {
+		j.Progress[event.Event] += event.Count
+		j.metrics.addResources(event)
+		return
+	}
+	j.logs = append(j.logs, line)
+}
+
+func (j *Job) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+func (j *Job) setStatus(status JobStatus, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	switch status {
+	case JobRunning:
+		j.Started = &now
+	case JobSucceeded, JobFailed:
+		if len(j.partial) > 0 {
+			j.addLine(string(j.partial))
+			j.partial = nil
+		}
+		j.Finished = &now
+	}
+	if err != nil {
+		j.Error = err.Error()
+	}
+	j.metrics.moveJob(j.Request.Provider, j.Status, status)
+	j.Status = status
+	j.notify()
+}
+
+func (j *Job) done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// logsFrom returns the log lines after the first n, whether the job is finished,
+// and a channel closed on the next change
+func (j *Job) logsFrom(n int) ([]string, bool, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var lines []string
+	if n < len(j.logs) {
+		lines = append(lines, j.logs[n:]...)
+	}
+	return lines, j.done(), j.changed
+}
+
+// MarshalJSON locks the job, it changes while it runs
+func (j *Job) MarshalJSON() ([]byte, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	type job Job
+	progress := map[string]int{}
+	for k, v := range j.Progress {
+		progress[k] = v
+	}
+	copied := job{
+		ID:       j.ID,
+		Request:  j.Request,
+		Status:   j.Status,
+		Error:    j.Error,
+		Progress: progress,
+		Created:  j.Created,
+		Started:  j.Started,
+		Finished: j.Finished,
+	}
+	return json.Marshal(&copied)
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// Metrics are written in the Prometheus text format, a handful of counters don't need the client library
+type Metrics struct {
+	mu        sync.Mutex
+	resources map[string]map[string]int // by progress event and provider
+	jobs      map[string]map[JobStatus]int
+}
+
+var resourceMetrics = []struct {
+	event, name, help string
+}{
+	{terraformutils.ProgressDiscovered, "terraformer_resources_discovered_total", "Resources found by services."},
+	{terraformutils.ProgressRefreshed, "terraformer_resources_refreshed_total", "Resources read by the provider plugin."},
+	{terraformutils.ProgressFailed, "terraformer_resources_failed_total", "Resources the provider plugin failed to read."},
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		resources: map[string]map[string]int{},
+		jobs:      map[string]map[JobStatus]int{},
+	}
+}
+
+func (m *Metrics) addResources(event terraformutils.ProgressEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.resources[event.Event] == nil {
+		m.resources[event.Event] = map[string]int{}
+	}
+	m.resources[event.Event][event.Provider] += event.Count
+}
+
+func (m *Metrics) moveJob(provider string, from, to JobStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jobs[provider] == nil {
+		m.jobs[provider] = map[JobStatus]int{}
+	}
+	if from != "" && from != JobSucceeded && from != JobFailed {
+		m.jobs[provider][from]--
+	}
+	m.jobs[provider][to]++
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	write := func(format string, args ...interface{}) error {
+		written, err := fmt.Fprintf(w, format, args...)
+		n += int64(written)
+		return err
+	}
+	for _, metric := range resourceMetrics {
+		if err := write("# HELP %s %s\n# TYPE %s counter\n", metric.name, metric.help, metric.name); err != nil {
+			return n, err
+		}
+		byProvider := m.resources[metric.event]
+		for _, provider := range sortedKeys(byProvider) {
+			if err := write("%s{provider=%s} %d\n", metric.name, strconv.Quote(provider), byProvider[provider]); err != nil {
+				return n, err
+			}
+		}
+	}
+	// queued and running jobs are current values, finished jobs only grow
+	if err := write("# HELP terraformer_jobs Import jobs by status.\n# TYPE terraformer_jobs gauge\n"); err != nil {
+		return n, err
+	}
+	providers := map[string]int{}
+	for provider := range m.jobs {
+		providers[provider] = 0
+	}
+	for _, provider := range sortedKeys(providers) {
+		for _, status := range []JobStatus{JobQueued, JobRunning, JobSucceeded, JobFailed} {
+			if err := write("terraformer_jobs{provider=%s,status=%q} %d\n", strconv.Quote(provider), status, m.jobs[provider][status]); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,448 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server runs imports as jobs behind an HTTP API.
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errJobNotFound = errors.New("job not found")
+
+// Runner imports the request of the job into the directory of the job, output are its logs
+type Runner func(ctx context.Context, job *Job, output io.Writer) error
+
+// Server queues jobs and runs at most concurrency of them at once
+type Server struct {
+	// Validate rejects requests before they are queued, optional
+	Validate func(JobRequest) error
+	// Token is required as "Authorization: Bearer <token>" header of every request when it is set
+	Token string
+	// Retention is how long finished jobs and their directories are kept, 0 keeps them until they are deleted
+	Retention time.Duration
+
+	dir     string
+	runner  Runner
+	queue   chan *Job
+	metrics *Metrics
+	ctx     context.Context
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	ids  []string
+}
+
+// New starts the workers, they stop when ctx is done
+func New(ctx context.Context, dir string, concurrency int, runner Runner) *Server {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s := &Server{
+		dir:     dir,
+		runner:  runner,
+		queue:   make(chan *Job, 1024),
+		metrics: NewMetrics(),
+		ctx:     ctx,
+		jobs:    map[string]*Job{},
+	}
+	for i := 0; i < concurrency; i++ {
+		go s.work()
+	}
+	return s
+}
+
+func (s *Server) work() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case job := <-s.queue:
+			job.setStatus(JobRunning, nil)
+			err := s.runner(s.ctx, job, job)
+			if err != nil {
+				log.Printf("job %s failed: %v", job.ID, err)
+				job.setStatus(JobFailed, err)
+			} else {
+				job.setStatus(JobSucceeded, nil)
+			}
+		}
+	}
+}
+
+// Submit queues the import of the request
+func (s *Server) Submit(request JobRequest) (*Job, error) {
+	if request.Provider == "" {
+		return nil, errors.New("provider is missing")
+	}
+	if s.Validate != nil {
+		if err := s.Validate(request); err != nil {
+			return nil, err
+		}
+	}
+	s.prune()
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	job := newJob(hex.EncodeToString(id), request, filepath.Join(s.dir, hex.EncodeToString(id)), s.metrics)
+	if err := os.MkdirAll(job.Dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.ids = append(s.ids, job.ID)
+	s.mu.Unlock()
+	s.metrics.moveJob(request.Provider, "", JobQueued)
+	select {
+	case s.queue <- job:
+	default:
+		job.setStatus(JobFailed, errors.New("too many queued jobs"))
+	}
+	return job, nil
+}
+
+func (s *Server) job(id string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+// Delete removes the finished job and its directory
+func (s *Server) Delete(id string) error {
+	s.mu.Lock()
+	job := s.jobs[id]
+	if job == nil {
+		s.mu.Unlock()
+		return errJobNotFound
+	}
+	job.mu.Lock()
+	done, status := job.done(), job.Status
+	job.mu.Unlock()
+	if !done {
+		s.mu.Unlock()
+		return errors.New("job is " + string(status))
+	}
+	s.remove(id)
+	s.mu.Unlock()
+	return os.RemoveAll(job.Dir)
+}
+
+// remove forgets the job, s.mu is held
+func (s *Server) remove(id string) {
+	delete(s.jobs, id)
+	for i := range s.ids {
+		if s.ids[i] == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// prune removes jobs which finished longer than Retention ago with their directories
+func (s *Server) prune() {
+	if s.Retention <= 0 {
+		return
+	}
+	expired := time.Now().Add(-s.Retention)
+	var dirs []string
+	s.mu.Lock()
+	for _, id := range append([]string(nil), s.ids...) {
+		job := s.jobs[id]
+		job.mu.Lock()
+		remove := job.done() && job.Finished != nil && job.Finished.Before(expired)
+		job.mu.Unlock()
+		if remove {
+			s.remove(id)
+			dirs = append(dirs, job.Dir)
+		}
+	}
+	s.mu.Unlock()
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("can't remove job directory %s: %v", dir, err)
+		}
+	}
+}
+
+func (s *Server) list() []*Job {
+	s.prune()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*Job
+	for _, id := range s.ids {
+		jobs = append(jobs, s.jobs[id])
+	}
+	return jobs
+}
+
+// Handler serves the API:
+//
+//	POST /jobs                      queue a JobRequest
+//	GET  /jobs                      list jobs
+//	GET  /jobs/{id}                 status and progress of a job
+//	DELETE /jobs/{id}               remove a finished job and its files
+//	GET  /jobs/{id}/logs            logs, ?follow=true streams them until the job finishes
+//	GET  /jobs/{id}/plan            planfiles of the job
+//	GET  /jobs/{id}/report          import reports of the job
+//	GET  /jobs/{id}/output.tar.gz   generated files
+//	GET  /metrics                   Prometheus metrics
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", s.handleJobs)
+	mux.HandleFunc("/jobs/", s.handleJob)
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = s.metrics.WriteTo(w)
+	})
+	if s.Token == "" {
+		return mux
+	}
+	expected := []byte("Bearer " + s.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jobs := s.list()
+		if jobs == nil {
+			jobs = []*Job{}
+		}
+		writeJSON(w, http.StatusOK, jobs)
+	case http.MethodPost:
+		var request JobRequest
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		job, err := s.Submit(request)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, job)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/", 2)
+	resource := ""
+	if len(parts) == 2 {
+		resource = parts[1]
+	}
+	if r.Method == http.MethodDelete && resource == "" {
+		switch err := s.Delete(parts[0]); {
+		case err == errJobNotFound:
+			writeError(w, http.StatusNotFound, err)
+		case err != nil:
+			writeError(w, http.StatusConflict, err)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	job := s.job(parts[0])
+	if job == nil {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+		return
+	}
+	switch resource {
+	case "":
+		writeJSON(w, http.StatusOK, job)
+	case "logs":
+		streamLogs(w, r, job)
+	case "plan":
+		writeFiles(w, job, "plan.json")
+	case "report":
+		writeFiles(w, job, "report.json")
+	case "output.tar.gz":
+		if !finished(w, job) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", "attachment; filename="+job.ID+".tar.gz")
+		if err := writeTarball(w, job.Dir); err != nil {
+			log.Printf("job %s: can't write tarball: %v", job.ID, err)
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("unknown job resource "+resource))
+	}
+}
+
+func finished(w http.ResponseWriter, job *Job) bool {
+	job.mu.Lock()
+	done, status := job.done(), job.Status
+	job.mu.Unlock()
+	if !done {
+		writeError(w, http.StatusConflict, errors.New("job is "+string(status)))
+	}
+	return done
+}
+
+func streamLogs(w http.ResponseWriter, r *http.Request, job *Job) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	follow := r.URL.Query().Get("follow") == "true"
+	flusher, _ := w.(http.Flusher)
+	n := 0
+	for {
+		lines, done, changed := job.logsFrom(n)
+		for _, line := range lines {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return
+			}
+		}
+		n += len(lines)
+		if !follow || done {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeFiles writes the files with the name below the job directory, as a JSON object by relative path
+func writeFiles(w http.ResponseWriter, job *Job, name string) {
+	if !finished(w, job) {
+		return
+	}
+	files := map[string]json.RawMessage{}
+	err := filepath.Walk(job.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != name {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(job.Dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(files) == 0 {
+		writeError(w, http.StatusNotFound, errors.New("job has no "+name))
+		return
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+func writeTarball(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := addToTarball(tw, dir, path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addToTarball(tw *tar.Writer, dir, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,264 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRunner(ctx context.Context, job *Job, output io.Writer) error {
+	if job.Request.Provider == "broken" {
+		return errors.New("import failed")
+	}
+	fmt.Fprintln(output, "aws importing... vpc")
+	fmt.Fprintln(output, `terraformer-progress: {"event":"discovered","provider":"aws","service":"vpc","count":2}`)
+	fmt.Fprint(output, `terraformer-progress: {"event":"refreshed","provider":"aws","count":2}`+"\naws done")
+	dir := filepath.Join(job.Dir, "generated", "aws", "terraformer")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "plan.json"), []byte(`{"Provider":"aws"}`), os.ModePerm)
+}
+
+func request(t *testing.T, server *httptest.Server, method, path, body string) (int, string) {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := New(ctx, t.TempDir(), 1, testRunner)
+	s.Validate = func(r JobRequest) error {
+		if r.Provider == "unknown" {
+			return errors.New("unsupported provider: unknown")
+		}
+		return nil
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	if status, _ := request(t, server, http.MethodPost, "/jobs", `{"provider":"unknown"}`); status != http.StatusBadRequest {
+		t.Errorf("unknown provider got status %d", status)
+	}
+	status, body := request(t, server, http.MethodPost, "/jobs", `{"provider":"aws","options":{"Resources":["vpc"]}}`)
+	if status != http.StatusAccepted {
+		t.Fatalf("got status %d: %s", status, body)
+	}
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(body), &job); err != nil {
+		t.Fatal(err)
+	}
+	request(t, server, http.MethodPost, "/jobs", `{"provider":"broken"}`)
+
+	// following the logs returns when the job is finished
+	if _, logs := request(t, server, http.MethodGet, "/jobs/"+job.ID+"/logs?follow=true", ""); logs != "aws importing... vpc\naws done\n" {
+		t.Errorf("unexpected logs %q", logs)
+	}
+	_, body = request(t, server, http.MethodGet, "/jobs/"+job.ID, "")
+	if !strings.Contains(body, `"status": "succeeded"`) || !strings.Contains(body, `"discovered": 2`) {
+		t.Errorf("unexpected job %s", body)
+	}
+	if _, plan := request(t, server, http.MethodGet, "/jobs/"+job.ID+"/plan", ""); !strings.Contains(plan, `"generated/aws/terraformer/plan.json": {`) {
+		t.Errorf("unexpected plan %s", plan)
+	}
+	if status, _ := request(t, server, http.MethodGet, "/jobs/"+job.ID+"/report", ""); status != http.StatusNotFound {
+		t.Errorf("missing report got status %d", status)
+	}
+
+	resp, err := http.Get(server.URL + "/jobs/" + job.ID + "/output.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := tar.NewReader(gz).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Name != "generated/aws/terraformer/plan.json" {
+		t.Errorf("unexpected file %s in tarball", header.Name)
+	}
+
+	for {
+		_, jobs := request(t, server, http.MethodGet, "/jobs", "")
+		if strings.Count(jobs, `"id"`) != 2 {
+			t.Fatalf("unexpected jobs %s", jobs)
+		}
+		if strings.Contains(jobs, `"error": "import failed"`) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, metrics := request(t, server, http.MethodGet, "/metrics", "")
+	for _, expected := range []string{
+		`terraformer_resources_discovered_total{provider="aws"} 2`,
+		`terraformer_resources_refreshed_total{provider="aws"} 2`,
+		`terraformer_jobs{provider="aws",status="succeeded"} 1`,
+		`terraformer_jobs{provider="broken",status="failed"} 1`,
+		`terraformer_jobs{provider="broken",status="queued"} 0`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("metrics don't contain %s:\n%s", expected, metrics)
+		}
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := New(ctx, t.TempDir(), 1, testRunner)
+	s.Token = "secret"
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	if status, _ := request(t, server, http.MethodGet, "/jobs", ""); status != http.StatusUnauthorized {
+		t.Errorf("request without token got status %d", status)
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for token, expected := range map[string]int{"wrong": http.StatusUnauthorized, "secret": http.StatusOK} {
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("token %s got status %d, expected %d", token, resp.StatusCode, expected)
+		}
+	}
+}
+
+// waitForJob polls the job until it is finished
+func waitForJob(t *testing.T, s *Server, id string) *Job {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		job := s.job(id)
+		job.mu.Lock()
+		done := job.done()
+		job.mu.Unlock()
+		if done {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s didn't finish", id)
+	return nil
+}
+
+func TestServerDelete(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := New(ctx, t.TempDir(), 1, func(ctx context.Context, job *Job, output io.Writer) error {
+		close(started)
+		<-release
+		return nil
+	})
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	job, err := s.Submit(JobRequest{Provider: "aws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if status, _ := request(t, server, http.MethodDelete, "/jobs/"+job.ID, ""); status != http.StatusConflict {
+		t.Errorf("deleting a running job got status %d", status)
+	}
+	close(release)
+	waitForJob(t, s, job.ID)
+
+	if status, body := request(t, server, http.MethodDelete, "/jobs/"+job.ID, ""); status != http.StatusNoContent {
+		t.Fatalf("got status %d: %s", status, body)
+	}
+	if _, err := os.Stat(job.Dir); !os.IsNotExist(err) {
+		t.Errorf("job directory wasn't removed: %v", err)
+	}
+	if status, _ := request(t, server, http.MethodGet, "/jobs/"+job.ID, ""); status != http.StatusNotFound {
+		t.Errorf("deleted job got status %d", status)
+	}
+	if status, _ := request(t, server, http.MethodDelete, "/jobs/"+job.ID, ""); status != http.StatusNotFound {
+		t.Errorf("deleting a deleted job got status %d", status)
+	}
+}
+
+func TestServerRetention(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := New(ctx, t.TempDir(), 1, testRunner)
+	s.Retention = time.Hour
+
+	old, err := s.Submit(JobRequest{Provider: "aws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, s, old.ID)
+	old.mu.Lock()
+	finished := old.Finished.Add(-2 * time.Hour)
+	old.Finished = &finished
+	old.mu.Unlock()
+	recent, err := s.Submit(JobRequest{Provider: "aws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForJob(t, s, recent.ID)
+
+	jobs := s.list()
+	if len(jobs) != 1 || jobs[0].ID != recent.ID {
+		t.Errorf("expected only the recent job, got %v", jobs)
+	}
+	if _, err := os.Stat(old.Dir); !os.IsNotExist(err) {
+		t.Errorf("directory of the expired job wasn't removed: %v", err)
+	}
+	if _, err := os.Stat(recent.Dir); err != nil {
+		t.Errorf("directory of the recent job was removed: %v", err)
+	}
+}
@@ -15,6 +15,7 @@
 package terraformutils
 
 import (
+	"errors"
 	"log"
 	"strings"
 
@@ -40,6 +41,16 @@ type ServiceGenerator interface {
 	PostRefreshCleanup()
 }
 
+// BatchService is implemented by services which discover their resources page by page,
+// imports with --batch-size process every page before the next one is discovered
+type BatchService interface {
+	InitResourcesInBatches(batch func([]Resource) error) error
+}
+
+// ErrNoBatches is returned by InitResourcesInBatches of services wrapping services which
+// don't discover page by page, their resources are discovered with InitResources
+var ErrNoBatches = errors.New("service doesn't discover resources page by page")
+
 type Service struct {
 	Name         string
 	Resources    []Resource
@@ -71,6 +82,14 @@ func (s *Service) ParseFilters(rawFilters []string) {
 
 func (s *Service) ParseFilter(rawFilter string) []ResourceFilter {
 	var filters []ResourceFilter
+	if IsFilterExpression(rawFilter) {
+		expression, err := ParseFilterExpression(rawFilter)
+		if err != nil {
+			log.Print("Invalid filter: " + err.Error())
+			return filters
+		}
+		return append(filters, ResourceFilter{Expression: expression})
+	}
 	if !strings.HasPrefix(rawFilter, "Name=") && len(strings.Split(rawFilter, "=")) == 2 {
 		parts := strings.Split(rawFilter, "=")
 		serviceName, resourcesID := parts[0], parts[1]
@@ -0,0 +1,219 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StateResource is a managed resource found in an existing state file
+type StateResource struct {
+	Type string
+	Name string
+	ID   string
+	// Attributes are the flat attributes of the resource, attributes of version 4 states
+	// are flattened like the ones of version 3
+	Attributes map[string]string
+	// Path is the state file of the resource
+	Path string
+}
+
+func (r StateResource) Address() string {
+	return r.Type + "." + r.Name
+}
+
+type stateFile struct {
+	Version int `json:"version"`
+	// v3
+	Modules []struct {
+		Resources map[string]struct {
+			Type    string `json:"type"`
+			Primary struct {
+				ID         string            `json:"id"`
+				Attributes map[string]string `json:"attributes"`
+			} `json:"primary"`
+		} `json:"resources"`
+	} `json:"modules"`
+	// v4
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			Attributes     map[string]interface{} `json:"attributes"`
+			AttributesFlat map[string]string      `json:"attributes_flat"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// ReadStateResources reads managed resources from a state file or from all
+// *.tfstate files in a directory tree. Version 3 and 4 states are supported.
+func ReadStateResources(path string) ([]StateResource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readStateFile(path)
+	}
+	var resources []StateResource
+	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".tfstate") {
+			return nil
+		}
+		stateResources, err := readStateFile(filePath)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, stateResources...)
+		return nil
+	})
+	return resources, err
+}
+
+func readStateFile(path string) ([]StateResource, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := stateFile{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %v", path, err)
+	}
+	var resources []StateResource
+	switch state.Version {
+	case 3:
+		for _, module := range state.Modules {
+			for address, resource := range module.Resources {
+				if strings.HasPrefix(address, "data.") {
+					continue
+				}
+				resources = append(resources, StateResource{
+					Type:       resource.Type,
+					Name:       strings.TrimPrefix(address, resource.Type+"."),
+					ID:         resource.Primary.ID,
+					Attributes: resource.Primary.Attributes,
+					Path:       path,
+				})
+			}
+		}
+	case 4:
+		for _, resource := range state.Resources {
+			if resource.Mode != "managed" {
+				continue
+			}
+			for _, instance := range resource.Instances {
+				// states upgraded from version 3 keep flat attributes until Terraform writes them
+				attributes := instance.AttributesFlat
+				if attributes == nil {
+					attributes = map[string]string{}
+					flattenObject("", instance.Attributes, attributes)
+				}
+				resources = append(resources, StateResource{
+					Type:       resource.Type,
+					Name:       resource.Name,
+					ID:         attributes["id"],
+					Attributes: attributes,
+					Path:       path,
+				})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported state version %d in %s", state.Version, path)
+	}
+	return resources, nil
+}
+
+// flattenAttributes writes value like the flatmap of version 3 states, with .# counts of
+// lists and sets and .% counts of maps. Objects in lists are nested blocks, they have no count.
+func flattenAttributes(key string, value interface{}, flat map[string]string) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		flat[key+".%"] = strconv.Itoa(len(v))
+		flattenObject(key+".", v, flat)
+	case []interface{}:
+		flat[key+".#"] = strconv.Itoa(len(v))
+		for i, element := range v {
+			elementKey := key + "." + strconv.Itoa(i)
+			if object, ok := element.(map[string]interface{}); ok {
+				flattenObject(elementKey+".", object, flat)
+			} else {
+				flattenAttributes(elementKey, element, flat)
+			}
+		}
+	case float64:
+		flat[key] = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		flat[key] = fmt.Sprint(v)
+	}
+}
+
+func flattenObject(prefix string, object map[string]interface{}, flat map[string]string) {
+	for k, v := range object {
+		flattenAttributes(prefix+k, v, flat)
+	}
+}
+
+// ManagedResources are the resources of existing states by type and ID
+type ManagedResources map[string]map[string]StateResource
+
+// ReadManagedResources reads the resources of state files or directories, see ReadStateResources
+func ReadManagedResources(paths []string) (ManagedResources, error) {
+	managed := ManagedResources{}
+	for _, path := range paths {
+		resources, err := ReadStateResources(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			if resource.ID == "" {
+				continue
+			}
+			if managed[resource.Type] == nil {
+				managed[resource.Type] = map[string]StateResource{}
+			}
+			managed[resource.Type][resource.ID] = resource
+		}
+	}
+	return managed, nil
+}
+
+// Exclude drops resources with the type and ID of a managed resource, they are added to the report
+func (m ManagedResources) Exclude(resources []Resource, report *ImportReport) []Resource {
+	kept := []Resource{}
+	for _, resource := range resources {
+		managed, exist := m[resource.InstanceInfo.Type][resource.InstanceState.ID]
+		if !exist {
+			kept = append(kept, resource)
+			continue
+		}
+		log.Printf("excluding %s, it is managed as %s in %s", resource.InstanceInfo.Id, managed.Address(), managed.Path)
+		if report != nil {
+			report.AddExcluded(resource.InstanceInfo.Id, "managed as "+managed.Address()+" in "+managed.Path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,167 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const stateV4 = `{
+  "version": 4,
+  "resources": [
+    {"mode": "data", "type": "aws_ami", "name": "ubuntu", "instances": [{"attributes": {"id": "ami-1"}}]},
+    {"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-1", "enable_dns_support": true,
+      "cidr_block": "10.0.0.0/16", "ipv6_netmask_length": 0, "ipv6_cidr_block": null, "tags": {"env": "prod"},
+      "ingress": [{"from_port": 443, "cidr_blocks": ["0.0.0.0/0"]}]}}]}
+  ]
+}`
+
+func TestReadStateResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state, err := PrintTfState([]Resource{NewSimpleResource("subnet-1", "public", "aws_subnet", "aws", []string{})}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "subnet"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "subnet", "terraform.tfstate"), state, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "vpc.tfstate"), []byte(stateV4), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	resources, err := ReadStateResources(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Type < resources[j].Type })
+	expected := []StateResource{
+		{Type: "aws_subnet", Name: "tfer--public", ID: "subnet-1", Attributes: map[string]string{}, Path: filepath.Join(dir, "subnet", "terraform.tfstate")},
+		{Type: "aws_vpc", Name: "main", ID: "vpc-1", Attributes: map[string]string{
+			"id":                      "vpc-1",
+			"enable_dns_support":      "true",
+			"cidr_block":              "10.0.0.0/16",
+			"ipv6_netmask_length":     "0",
+			"tags.%":                  "1",
+			"tags.env":                "prod",
+			"ingress.#":               "1",
+			"ingress.0.from_port":     "443",
+			"ingress.0.cidr_blocks.#": "1",
+			"ingress.0.cidr_blocks.0": "0.0.0.0/0",
+		}, Path: filepath.Join(dir, "vpc.tfstate")},
+	}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("unexpected resources %v", resources)
+	}
+}
+
+func TestPrintTfStateV4(t *testing.T) {
+	vpc := NewSimpleResource("vpc-1", "main", "aws_vpc", "aws", []string{})
+	vpc.ProviderAlias = "eu_west_1"
+	subnet := NewSimpleResource("subnet-1", "public", "aws_subnet", "aws", []string{})
+	state, err := PrintTfStateV4([]Resource{vpc, subnet}, map[string]string{"aws": "registry.opentofu.org/hashicorp/aws"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"version": 4`,
+		`"provider": "provider[\"registry.opentofu.org/hashicorp/aws\"].eu_west_1"`,
+		`"provider": "provider[\"registry.opentofu.org/hashicorp/aws\"]"`,
+	} {
+		if !strings.Contains(string(state), expected) {
+			t.Errorf("state doesn't contain %s:\n%s", expected, state)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "terraform.tfstate"), state, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	resources, err := ReadStateResources(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("unexpected resources %v", resources)
+	}
+	for _, r := range resources {
+		if r.Attributes == nil || r.Attributes["id"] != r.ID {
+			t.Errorf("flat attributes of %s weren't read: %v", r.Address(), r.Attributes)
+		}
+	}
+}
+
+func TestManagedResourcesExclude(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "network.tfstate")
+	if err := ioutil.WriteFile(path, []byte(stateV4), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	managed, err := ReadManagedResources([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := NewImportReport()
+	resources := managed.Exclude([]Resource{
+		NewSimpleResource("vpc-1", "main", "aws_vpc", "aws", []string{}),
+		NewSimpleResource("vpc-2", "other", "aws_vpc", "aws", []string{}),
+		// same ID as the data source, which isn't managed
+		NewSimpleResource("ami-1", "ubuntu", "aws_ami", "aws", []string{}),
+	}, report)
+	if len(resources) != 2 || resources[0].InstanceState.ID != "vpc-2" || resources[1].InstanceState.ID != "ami-1" {
+		t.Errorf("unexpected resources %v", resources)
+	}
+	expected := map[string][]string{"aws_vpc.tfer--main": {"managed as aws_vpc.main in " + path}}
+	if !reflect.DeepEqual(report.Excluded, expected) {
+		t.Errorf("unexpected report %v", report.Excluded)
+	}
+}
+
+func TestPrintTfStateDeterministic(t *testing.T) {
+	vpc := NewSimpleResource("vpc-1", "main", "aws_vpc", "aws", []string{})
+	subnet := NewSimpleResource("subnet-1", "public", "aws_subnet", "aws", []string{})
+	duplicate := NewSimpleResource("subnet-2", "public", "aws_subnet", "aws", []string{})
+	lineage := StableLineage("aws/vpc")
+	state, err := PrintTfState([]Resource{vpc, duplicate, subnet}, lineage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reordered, err := PrintTfState([]Resource{subnet, vpc, duplicate}, lineage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(state) != string(reordered) {
+		t.Errorf("state depends on order:\n%s\n%s", state, reordered)
+	}
+	if !strings.Contains(string(state), `"lineage": "`+lineage+`"`) || strings.Contains(string(state), "subnet-2") {
+		t.Errorf("unexpected state %s", state)
+	}
+	if lineage == StableLineage("aws/subnet") {
+		t.Errorf("lineages of different directories should differ")
+	}
+}
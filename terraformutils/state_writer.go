@@ -0,0 +1,219 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// StateWriter writes a state file batch by batch, so resources don't have to be held in memory at once.
+// Every batch is printed like PrintTfState or PrintTfStateV4 prints states, its resources and outputs
+// are copied into the file. Outputs come after the resources and wait in a temporary file until Close.
+type StateWriter struct {
+	providerSources map[string]string
+	lineage         string
+	file            *os.File
+	w               *bufio.Writer
+	outputs         *os.File
+	outputsW        *bufio.Writer
+	resources       int
+	outputCount     int
+}
+
+// NewStateWriter creates the state file, with providerSources it is written in the version 4 format.
+// lineage is a new one when it's empty.
+func NewStateWriter(path string, providerSources map[string]string, lineage string) (*StateWriter, error) {
+	if lineage == "" {
+		var err error
+		if lineage, err = uuid.GenerateUUID(); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := ioutil.TempFile("", "terraformer-outputs")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	s := &StateWriter{
+		providerSources: providerSources,
+		lineage:         lineage,
+		file:            file,
+		w:               bufio.NewWriter(file),
+		outputs:         outputs,
+		outputsW:        bufio.NewWriter(outputs),
+	}
+	if providerSources != nil {
+		_, err = fmt.Fprintf(s.w, "{\n  \"version\": 4,\n  \"terraform_version\": %q,\n  \"serial\": %d,\n  \"lineage\": %q,\n  \"resources\": [", terraform.VersionString(), StateSerial, lineage) //nolint
+	} else {
+		_, err = fmt.Fprintf(s.w, "{\n  \"version\": %d,\n  \"terraform_version\": %q,\n  \"serial\": %d,\n  \"lineage\": %q,\n  \"modules\": [\n    {\n      \"path\": [\n        \"root\"\n      ],\n      \"resources\": {", terraform.StateVersion, terraform.VersionString(), StateSerial, lineage) //nolint
+	}
+	if err != nil {
+		s.remove()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write adds the resources and their outputs to the state
+func (s *StateWriter) Write(resources []Resource) error {
+	var resourceEntries, outputEntries []stateEntry
+	if s.providerSources != nil {
+		data, err := PrintTfStateV4(resources, s.providerSources, s.lineage)
+		if err != nil {
+			return err
+		}
+		var state struct {
+			Outputs   map[string]json.RawMessage `json:"outputs"`
+			Resources []json.RawMessage          `json:"resources"`
+		}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return err
+		}
+		for _, r := range state.Resources {
+			resourceEntries = append(resourceEntries, stateEntry{value: r})
+		}
+		outputEntries = sortedStateEntries(state.Outputs)
+	} else {
+		data, err := PrintTfState(resources, s.lineage)
+		if err != nil {
+			return err
+		}
+		var state struct {
+			Modules []struct {
+				Outputs   map[string]json.RawMessage `json:"outputs"`
+				Resources map[string]json.RawMessage `json:"resources"`
+			} `json:"modules"`
+		}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return err
+		}
+		for _, module := range state.Modules {
+			resourceEntries = append(resourceEntries, sortedStateEntries(module.Resources)...)
+			outputEntries = append(outputEntries, sortedStateEntries(module.Outputs)...)
+		}
+	}
+	indent := "        "
+	if s.providerSources != nil {
+		indent = "    "
+	}
+	for _, entry := range resourceEntries {
+		if err := entry.write(s.w, s.resources == 0, indent); err != nil {
+			return err
+		}
+		s.resources++
+	}
+	for _, entry := range outputEntries {
+		if err := entry.write(s.outputsW, s.outputCount == 0, indent); err != nil {
+			return err
+		}
+		s.outputCount++
+	}
+	return nil
+}
+
+// Close copies the outputs into the state and closes it
+func (s *StateWriter) Close() error {
+	defer s.remove()
+	// closing brackets of resources and outputs, then of the module and the state
+	indent, resourcesEnd, tail := "      ", "}", "\n      \"depends_on\": []\n    }\n  ]\n}\n"
+	if s.providerSources != nil {
+		indent, resourcesEnd, tail = "  ", "]", "\n}\n"
+	}
+	if s.resources > 0 {
+		resourcesEnd = "\n" + indent + resourcesEnd
+	}
+	if _, err := io.WriteString(s.w, resourcesEnd+",\n"+indent+"\"outputs\": {"); err != nil {
+		return err
+	}
+	if err := s.outputsW.Flush(); err != nil {
+		return err
+	}
+	if _, err := s.outputs.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(s.w, s.outputs); err != nil {
+		return err
+	}
+	outputsEnd := "}"
+	if s.outputCount > 0 {
+		outputsEnd = "\n" + indent + outputsEnd
+	}
+	if s.providerSources == nil {
+		outputsEnd += ","
+	}
+	if _, err := io.WriteString(s.w, outputsEnd+tail); err != nil {
+		return err
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+func (s *StateWriter) remove() {
+	s.file.Close()
+	s.outputs.Close()
+	os.Remove(s.outputs.Name())
+}
+
+// stateEntry is a resource or output of a state, key is empty for entries of a list
+type stateEntry struct {
+	key   string
+	value json.RawMessage
+}
+
+func sortedStateEntries(m map[string]json.RawMessage) []stateEntry {
+	var entries []stateEntry
+	for k, v := range m {
+		entries = append(entries, stateEntry{key: k, value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+func (e stateEntry) write(w io.Writer, first bool, indent string) error {
+	var buf bytes.Buffer
+	if !first {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\n" + indent)
+	if e.key != "" {
+		key, err := json.Marshal(e.key)
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteString(": ")
+	}
+	if err := json.Indent(&buf, e.value, indent, "  "); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestStateWriter(t *testing.T) {
+	vpc := NewSimpleResource("vpc-1", "main", "aws_vpc", "aws", []string{})
+	vpc.Outputs = map[string]*terraform.OutputState{"aws_vpc_tfer--main_id": {Type: "string", Value: "vpc-1"}}
+	subnets := []Resource{
+		NewSimpleResource("subnet-1", "a", "aws_subnet", "aws", []string{}),
+		NewSimpleResource("subnet-2", "b", "aws_subnet", "aws", []string{}),
+	}
+	for name, providerSources := range map[string]map[string]string{
+		"v3": nil,
+		"v4": {"aws": "registry.opentofu.org/hashicorp/aws"},
+	} {
+		for _, batches := range [][][]Resource{{{vpc}, subnets}, {}} {
+			path := filepath.Join(t.TempDir(), "terraform.tfstate")
+			w, err := NewStateWriter(path, providerSources, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := 0
+			for _, batch := range batches {
+				if err := w.Write(batch); err != nil {
+					t.Fatal(err)
+				}
+				expected += len(batch)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs := 0
+			if providerSources == nil {
+				// terraform 0.12 reads the legacy provider addresses only
+				state, err := statefile.Read(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if len(state.State.RootModule().Resources) != expected {
+					t.Errorf("%s: got %d resources, expected %d", name, len(state.State.RootModule().Resources), expected)
+				}
+				outputs = len(state.State.RootModule().OutputValues)
+			} else {
+				var state struct {
+					Outputs map[string]interface{} `json:"outputs"`
+				}
+				if err := json.Unmarshal(data, &state); err != nil {
+					t.Fatalf("%s: %v\n%s", name, err, data)
+				}
+				outputs = len(state.Outputs)
+			}
+			if expected > 0 && outputs != 1 {
+				t.Errorf("%s: got %d outputs", name, outputs)
+			}
+			if resources, err := ReadStateResources(path); err != nil || len(resources) != expected {
+				t.Errorf("%s: read %v %v", name, resources, err)
+			}
+		}
+	}
+}
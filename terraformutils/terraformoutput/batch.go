@@ -0,0 +1,152 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// BatchWriter writes resources into a directory batch by batch. Files are the ones of OutputHclFiles,
+// printed batches are appended to them, so memory doesn't grow with the number of resources.
+// Only HCL is written, JSON files can't be appended to.
+type BatchWriter struct {
+	provider    terraformutils.ProviderGenerator
+	path        string
+	serviceName string
+	isCompact   bool
+	documents   string
+	state       *terraformutils.StateWriter
+	files       map[string]*os.File
+	// written are the addresses of written resources, an address is only written by its first batch
+	written map[string]bool
+}
+
+// NewBatchWriter writes the provider file and starts the state, see NewStateWriter for providerSources and lineage
+func NewBatchWriter(provider terraformutils.ProviderGenerator, path string, serviceName string, isCompact bool, documents string, providerSources map[string]string, lineage string) (*BatchWriter, error) {
+	if err := OutputProviderFile(provider, path, "hcl"); err != nil {
+		return nil, err
+	}
+	state, err := terraformutils.NewStateWriter(filepath.Join(path, "terraform.tfstate"), providerSources, lineage)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchWriter{
+		provider:    provider,
+		path:        path,
+		serviceName: serviceName,
+		isCompact:   isCompact,
+		documents:   documents,
+		state:       state,
+		files:       map[string]*os.File{},
+		written:     map[string]bool{},
+	}, nil
+}
+
+// Write appends the resources, their outputs and their state. Of resources of the batch with the
+// same address, the one with the lowest ID is written, like HclPrintResource does without batches.
+// Written resources can't be replaced, so resources with the address of a resource of an earlier
+// batch are skipped even with a lower ID, their files would declare the resource twice.
+func (w *BatchWriter) Write(resources []terraformutils.Resource) error {
+	var unwritten []terraformutils.Resource
+	indexes := map[string]int{}
+	for _, r := range resources {
+		if w.written[r.Address()] {
+			log.Printf("%s is written by an earlier batch, skipping it", r.Address())
+			continue
+		}
+		if i, exist := indexes[r.Address()]; !exist {
+			indexes[r.Address()] = len(unwritten)
+			unwritten = append(unwritten, r)
+		} else if r.InstanceState.ID < unwritten[i].InstanceState.ID {
+			unwritten[i] = r
+		}
+	}
+	if len(unwritten) == 0 {
+		return nil
+	}
+	for address := range indexes {
+		w.written[address] = true
+	}
+	resources = unwritten
+
+	outputsByResource := resourceOutputs(resources, w.provider, w.serviceName)
+	if len(outputsByResource) > 0 {
+		outputsFile, err := terraformutils.Print(map[string]interface{}{"output": outputsByResource}, map[string]struct{}{}, "hcl")
+		if err != nil {
+			return err
+		}
+		if err := w.appendFile("outputs", outputsFile); err != nil {
+			return err
+		}
+	}
+
+	var types []string
+	typeOfServices := map[string][]terraformutils.Resource{}
+	for _, r := range resources {
+		if _, exist := typeOfServices[r.InstanceInfo.Type]; !exist {
+			types = append(types, r.InstanceInfo.Type)
+		}
+		typeOfServices[r.InstanceInfo.Type] = append(typeOfServices[r.InstanceInfo.Type], r)
+	}
+	for _, resourceType := range types {
+		fileName := resourceFileName(resourceType)
+		if w.isCompact {
+			fileName = "resources"
+		}
+		tfFile, err := renderFile(typeOfServices[resourceType], w.path, "hcl", w.documents)
+		if err != nil {
+			return err
+		}
+		if err := w.appendFile(fileName, tfFile); err != nil {
+			return err
+		}
+	}
+	return w.state.Write(resources)
+}
+
+// appendFile creates the file on the first batch, so files of earlier imports are replaced
+func (w *BatchWriter) appendFile(name string, data []byte) error {
+	f, exist := w.files[name]
+	if !exist {
+		var err error
+		f, err = os.Create(filepath.Join(w.path, name+"."+GetFileExtension("hcl")))
+		if err != nil {
+			return err
+		}
+		w.files[name] = f
+	} else if _, err := f.WriteString("\n"); err != nil {
+		return err
+	}
+	_, err := f.Write(data)
+	return err
+}
+
+// Close closes the files and finishes the state
+func (w *BatchWriter) Close() error {
+	var firstErr error
+	for _, f := range w.files {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := w.state.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
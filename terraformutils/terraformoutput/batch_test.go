@@ -0,0 +1,177 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+type testProvider struct {
+	terraformutils.Provider
+}
+
+func (p *testProvider) Init(args []string) error                           { return nil }
+func (p *testProvider) InitService(serviceName string, verbose bool) error { return nil }
+func (p *testProvider) GetName() string                                    { return "aws" }
+func (p *testProvider) GetProviderData(arg ...string) map[string]interface{} {
+	return map[string]interface{}{}
+}
+func (p *testProvider) GetResourceConnections() map[string]map[string][]string {
+	return map[string]map[string][]string{}
+}
+
+func TestBatchWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := NewBatchWriter(&testProvider{}, dir, "vpc", false, DocumentsString, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, batch := range [][]string{{"a", "b"}, {"c"}} {
+		var resources []terraformutils.Resource
+		for _, name := range batch {
+			r := terraformutils.NewSimpleResource("vpc-"+name, name, "aws_vpc", "aws", []string{})
+			r.InstanceState.Attributes["id"] = "vpc-" + name
+			r.Item = map[string]interface{}{"cidr_block": "10.0.0.0/16"}
+			resources = append(resources, r)
+			names = append(names, name)
+		}
+		if err := w.Write(resources); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "vpc.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, "vpc.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid resources %s: %s", data, diags.Error())
+	}
+	if blocks := file.Body.(*hclsyntax.Body).Blocks; len(blocks) != len(names) {
+		t.Errorf("expected %d resources, got %d in %s", len(names), len(blocks), data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outputs.tf")); err != nil {
+		t.Error(err)
+	}
+	resources, err := terraformutils.ReadStateResources(filepath.Join(dir, "terraform.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != len(names) {
+		t.Errorf("expected %d resources in state, got %v", len(names), resources)
+	}
+}
+
+func TestBatchWriterSkipsWrittenAddresses(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewBatchWriter(&testProvider{}, dir, "vpc", false, DocumentsString, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, batch := range [][]string{{"a", "b"}, {"b", "c"}, {"a"}} {
+		var resources []terraformutils.Resource
+		for _, name := range batch {
+			r := terraformutils.NewSimpleResource("vpc-"+name, name, "aws_vpc", "aws", []string{})
+			r.InstanceState.Attributes["id"] = "vpc-" + name
+			r.Item = map[string]interface{}{"cidr_block": "10.0.0.0/16"}
+			resources = append(resources, r)
+		}
+		if err := w.Write(resources); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "vpc.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, "vpc.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid resources %s: %s", data, diags.Error())
+	}
+	if blocks := file.Body.(*hclsyntax.Body).Blocks; len(blocks) != 3 {
+		t.Errorf("expected 3 resources, got %d in %s", len(blocks), data)
+	}
+	resources, err := terraformutils.ReadStateResources(filepath.Join(dir, "terraform.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 3 {
+		t.Errorf("expected 3 resources in state, got %v", resources)
+	}
+}
+
+func TestBatchWriterKeepsLowestIDOfBatch(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewBatchWriter(&testProvider{}, dir, "vpc", false, DocumentsString, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the lowest ID wins in a batch, the first batch wins over later ones
+	for _, batch := range [][]string{{"vpc-2", "vpc-1"}, {"vpc-0"}} {
+		var resources []terraformutils.Resource
+		for _, id := range batch {
+			r := terraformutils.NewSimpleResource(id, "main", "aws_vpc", "aws", []string{})
+			r.InstanceState.Attributes["id"] = id
+			r.Item = map[string]interface{}{"cidr_block": "10.0.0.0/16"}
+			resources = append(resources, r)
+		}
+		if err := w.Write(resources); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	resources, err := terraformutils.ReadStateResources(filepath.Join(dir, "terraform.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].Attributes["id"] != "vpc-1" {
+		t.Errorf("expected vpc-1 in state, got %v", resources)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "vpc.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, "vpc.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("invalid resources %s: %s", data, diags.Error())
+	}
+	if blocks := file.Body.(*hclsyntax.Body).Blocks; len(blocks) != 1 {
+		t.Errorf("expected 1 resource, got %d in %s", len(blocks), data)
+	}
+}
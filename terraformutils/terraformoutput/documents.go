@@ -0,0 +1,231 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	yaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// How JSON and YAML documents in string attributes are written
+const (
+	DocumentsString     = "string"
+	DocumentsJSONEncode = "jsonencode"
+	DocumentsFile       = "file"
+)
+
+const documentPlaceholderPrefix = "terraformer-document-"
+
+var yamlLineRe = regexp.MustCompile(`^(---|- |-$|[\w.\-"']+:(\s|$))`)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^0-9A-Za-z_\-.]`)
+
+type document struct {
+	format string // json or yaml
+	value  cty.Value
+	raw    string
+}
+
+// parseDocument detects JSON objects and arrays, and YAML mappings spanning several lines.
+// Heredocs added by providers are unwrapped. Documents with references are left alone.
+func parseDocument(s string) (document, bool) {
+	content := strings.TrimSpace(s)
+	if strings.HasPrefix(content, "<<") {
+		lines := strings.Split(content, "\n")
+		marker := strings.TrimLeft(lines[0], "<-")
+		if len(lines) < 3 || strings.TrimSpace(lines[len(lines)-1]) != marker {
+			return document{}, false
+		}
+		content = strings.TrimSpace(strings.Join(lines[1:len(lines)-1], "\n"))
+	}
+	if content == "" || strings.Contains(content, "${") {
+		return document{}, false
+	}
+	if content[0] == '{' || content[0] == '[' {
+		ty, err := ctyjson.ImpliedType([]byte(content))
+		if err != nil {
+			return document{}, false
+		}
+		value, err := ctyjson.Unmarshal([]byte(content), ty)
+		if err != nil {
+			return document{}, false
+		}
+		return document{format: "json", value: value, raw: content}, true
+	}
+	if !isYAMLMapping(content) {
+		return document{}, false
+	}
+	ty, err := yaml.ImpliedType([]byte(content))
+	if err != nil || !ty.IsObjectType() || len(ty.AttributeTypes()) == 0 {
+		return document{}, false
+	}
+	value, err := yaml.Unmarshal([]byte(content), ty)
+	if err != nil {
+		return document{}, false
+	}
+	return document{format: "yaml", value: value, raw: content + "\n"}, true
+}
+
+// isYAMLMapping avoids mistaking free text like descriptions for YAML
+func isYAMLMapping(content string) bool {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 {
+		return false
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		if !yamlLineRe.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// documentRenderer replaces documents in resource Items by placeholders
+// and turns them into expressions once the file is printed
+type documentRenderer struct {
+	mode        string
+	output      string
+	expressions map[string]string
+	dataFiles   map[string][]byte
+}
+
+func newDocumentRenderer(mode, output string) *documentRenderer {
+	return &documentRenderer{
+		mode:        mode,
+		output:      output,
+		expressions: map[string]string{},
+		dataFiles:   map[string][]byte{},
+	}
+}
+
+func (d *documentRenderer) enabled() bool {
+	switch d.mode {
+	case DocumentsFile:
+		return true
+	case DocumentsJSONEncode:
+		// JSON output has no native syntax for expressions outside of templates
+		return d.output == "hcl"
+	}
+	return false
+}
+
+// Resources returns copies of resources with rendered documents, Items of the originals are kept
+func (d *documentRenderer) Resources(resources []terraformutils.Resource) []terraformutils.Resource {
+	if !d.enabled() {
+		return resources
+	}
+	rendered := make([]terraformutils.Resource, len(resources))
+	for i, r := range resources {
+		rendered[i] = r
+		if r.Item == nil {
+			continue
+		}
+		prefix := r.InstanceInfo.Type + "_" + r.ResourceName
+		rendered[i].Item = d.render(r.Item, prefix).(map[string]interface{})
+	}
+	return rendered
+}
+
+func (d *documentRenderer) render(v interface{}, name string) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			copied[k] = d.render(item, name+"_"+k)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = d.render(item, fmt.Sprintf("%s_%d", name, i))
+		}
+		return copied
+	case string:
+		doc, ok := parseDocument(value)
+		if !ok {
+			return value
+		}
+		return d.expression(doc, name)
+	default:
+		return v
+	}
+}
+
+func (d *documentRenderer) expression(doc document, name string) string {
+	var expression string
+	switch d.mode {
+	case DocumentsFile:
+		fileName := unsafeFileNameChars.ReplaceAllString(name, "_") + "." + doc.format
+		d.dataFiles[fileName] = []byte(doc.raw)
+		expression = fmt.Sprintf(`file("${path.module}/data/%s")`, fileName)
+		if d.output == "json" {
+			return "${" + expression + "}"
+		}
+	default:
+		f := hclwrite.NewEmptyFile()
+		f.Body().SetAttributeRaw("value", hclwrite.TokensForValue(doc.value))
+		expression = doc.format + "encode(" + strings.TrimSpace(strings.TrimPrefix(string(f.Bytes()), "value = ")) + ")"
+	}
+	placeholder := fmt.Sprintf("%s%d", documentPlaceholderPrefix, len(d.expressions))
+	d.expressions[placeholder] = expression
+	return placeholder
+}
+
+// Replace puts expressions in place of placeholders of the printed file
+func (d *documentRenderer) Replace(data []byte) []byte {
+	if len(d.expressions) == 0 {
+		return data
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, documentPlaceholderPrefix) {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for placeholder, expression := range d.expressions {
+			expression = strings.ReplaceAll(expression, "\n", "\n"+indent)
+			line = strings.ReplaceAll(line, `"`+placeholder+`"`, expression)
+		}
+		lines[i] = line
+	}
+	return hclwrite.Format([]byte(strings.Join(lines, "\n")))
+}
+
+// DataFiles returns the documents to write into the data directory
+func (d *documentRenderer) DataFiles() map[string][]byte {
+	return d.dataFiles
+}
+
+func ValidateDocumentsMode(mode string) error {
+	switch mode {
+	case DocumentsString, DocumentsJSONEncode, DocumentsFile:
+		return nil
+	}
+	return fmt.Errorf("unknown documents mode %s, use %s, %s or %s", mode, DocumentsString, DocumentsJSONEncode, DocumentsFile)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+const policy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+
+func TestParseDocument(t *testing.T) {
+	cases := map[string]string{
+		policy:                                   "json",
+		"<<POLICY\n" + policy + "\nPOLICY":       "json",
+		"groups:\n  - name: admins\nversion: 2":  "yaml",
+		"Note: read this\nbefore deploying":      "",
+		"plain text":                             "",
+		`{"Resource": "${aws_s3_bucket.b.arn}"}`: "",
+	}
+	for s, format := range cases {
+		doc, ok := parseDocument(s)
+		if ok != (format != "") || doc.format != format {
+			t.Errorf("unexpected document %q for %q", doc.format, s)
+		}
+	}
+}
+
+func printDocumentResource(t *testing.T, documents string) (string, string) {
+	dir, err := ioutil.TempDir("", "terraformer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	r := terraformutils.NewSimpleResource("read", "read", "aws_iam_policy", "aws", []string{})
+	r.Item = map[string]interface{}{"name": "read", "policy": "<<POLICY\n" + policy + "\nPOLICY"}
+	if err := printFile([]terraformutils.Resource{r}, "iam", dir, "hcl", documents); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "iam.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, diags := hclsyntax.ParseConfig(data, "iam.tf", hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		t.Fatalf("invalid HCL %s: %s", data, diags.Error())
+	}
+	if r.Item["policy"] != "<<POLICY\n"+policy+"\nPOLICY" {
+		t.Error("Item of the resource must not change")
+	}
+	return dir, string(data)
+}
+
+func TestPrintDocumentsJSONEncode(t *testing.T) {
+	_, data := printDocumentResource(t, DocumentsJSONEncode)
+	if !strings.Contains(data, "policy = jsonencode({") || !strings.Contains(data, `Action   = "s3:GetObject"`) {
+		t.Errorf("policy is not rendered with jsonencode %s", data)
+	}
+}
+
+func TestPrintDocumentsFile(t *testing.T) {
+	dir, data := printDocumentResource(t, DocumentsFile)
+	if !strings.Contains(data, `policy = file("${path.module}/data/aws_iam_policy_tfer--read_policy.json")`) {
+		t.Errorf("policy is not rendered with file %s", data)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "data", "aws_iam_policy_tfer--read_policy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != policy {
+		t.Errorf("unexpected data file %s", content)
+	}
+}
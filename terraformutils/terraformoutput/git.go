@@ -0,0 +1,165 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// GitRepo is a local working tree generated files are committed to, with the git command
+type GitRepo struct {
+	Path string
+}
+
+// OpenGitRepo refuses working trees with uncommitted changes, they would end up in the commit
+// or be overwritten by generated files
+func OpenGitRepo(path string) (*GitRepo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	repo := &GitRepo{Path: absPath}
+	if _, err := repo.git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("%s is not a git working tree: %v", path, err)
+	}
+	status, err := repo.git("status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		return nil, fmt.Errorf("git repository %s has uncommitted changes, commit or stash them first:\n%s", path, status)
+	}
+	return repo, nil
+}
+
+func (g *GitRepo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Path
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.New("git " + strings.Join(args, " ") + ": " + strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// Checkout switches to the branch, it is created from the current commit when it doesn't exist
+func (g *GitRepo) Checkout(branch string) error {
+	if _, err := g.git("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		_, err = g.git("checkout", "-b", branch)
+		return err
+	}
+	_, err := g.git("checkout", branch)
+	return err
+}
+
+// Commit stages the changes of the paths and commits them, it returns false when nothing changed
+func (g *GitRepo) Commit(paths []string, message string) (bool, error) {
+	args := []string{"add", "--all", "--"}
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return false, err
+		}
+		args = append(args, absPath)
+	}
+	if _, err := g.git(args...); err != nil {
+		return false, err
+	}
+	if _, err := g.git("diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if _, err := g.git("commit", "--quiet", "--message", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ChangeSummary counts resources of a directory which differ from its previous state
+type ChangeSummary struct {
+	Added   int
+	Removed int
+	Changed int
+}
+
+func (s ChangeSummary) String() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed", s.Added, s.Removed, s.Changed)
+}
+
+// SummarizeChanges compares resources by type and ID with the previous state, a resource
+// is changed when its flat attributes differ
+func SummarizeChanges(previous []terraformutils.StateResource, resources []terraformutils.Resource) ChangeSummary {
+	summary := ChangeSummary{}
+	previousByKey := map[string]terraformutils.StateResource{}
+	for _, r := range previous {
+		previousByKey[r.Type+"/"+r.ID] = r
+	}
+	seen := map[string]bool{}
+	for _, r := range resources {
+		if r.DataSource {
+			continue
+		}
+		key := r.InstanceInfo.Type + "/" + r.InstanceState.ID
+		seen[key] = true
+		old, exist := previousByKey[key]
+		switch {
+		case !exist:
+			summary.Added++
+		case old.Attributes != nil && !equalAttributes(old.Attributes, r.InstanceState.Attributes):
+			summary.Changed++
+		}
+	}
+	for key := range previousByKey {
+		if !seen[key] {
+			summary.Removed++
+		}
+	}
+	return summary
+}
+
+func equalAttributes(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if value, exist := b[k]; !exist || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// GitCommitMessage lists the change summary of every directory below the subject
+func GitCommitMessage(subject string, summaries map[string]ChangeSummary) string {
+	var names []string
+	for name := range summaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, name+": "+summaries[name].String())
+	}
+	return subject + "\n\n" + strings.Join(lines, "\n") + "\n"
+}
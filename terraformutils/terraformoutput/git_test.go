@@ -0,0 +1,138 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func testGitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir := t.TempDir()
+	repo := &GitRepo{Path: dir}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "terraformer"},
+		{"config", "user.email", "terraformer@example.com"},
+		{"commit", "--quiet", "--allow-empty", "--message", "initial"},
+	} {
+		if _, err := repo.git(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGitRepoCommit(t *testing.T) {
+	dir := testGitRepo(t)
+	generated := filepath.Join(dir, "generated")
+	if err := os.MkdirAll(generated, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("local change"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenGitRepo(dir); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Fatalf("uncommitted changes weren't refused: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := OpenGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Checkout("import"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(generated, "vpc.tf"), []byte("vpc"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte("not generated"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	committed, err := repo.Commit([]string{generated}, "import\n\nvpc: 1 added, 0 removed, 0 changed\n")
+	if err != nil || !committed {
+		t.Fatalf("nothing committed: %v", err)
+	}
+	files, _ := repo.git("show", "--name-only", "--format=%s", "import")
+	if files != "import\n\ngenerated/vpc.tf" {
+		t.Errorf("unexpected commit %q", files)
+	}
+	if committed, err := repo.Commit([]string{generated}, "import"); err != nil || committed {
+		t.Errorf("unchanged files were committed: %v", err)
+	}
+	if err := repo.Checkout("import"); err != nil {
+		t.Error(err)
+	}
+	if status, _ := repo.git("status", "--porcelain"); status != "?? other.txt" {
+		t.Errorf("files outside of the paths were staged: %q", status)
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	previous := []terraformutils.StateResource{
+		{Type: "aws_vpc", ID: "vpc-1", Attributes: map[string]string{"cidr_block": "10.0.0.0/16"}},
+		{Type: "aws_vpc", ID: "vpc-2", Attributes: map[string]string{}},
+		{Type: "aws_vpc", ID: "vpc-3", Attributes: map[string]string{}},
+	}
+	resources := []terraformutils.Resource{
+		terraformutils.NewResource("vpc-1", "a", "aws_vpc", "aws", map[string]string{"cidr_block": "10.1.0.0/16"}, []string{}, map[string]interface{}{}),
+		terraformutils.NewResource("vpc-2", "b", "aws_vpc", "aws", map[string]string{}, []string{}, map[string]interface{}{}),
+		terraformutils.NewResource("vpc-4", "d", "aws_vpc", "aws", map[string]string{}, []string{}, map[string]interface{}{}),
+	}
+	summary := SummarizeChanges(previous, resources)
+	if summary != (ChangeSummary{Added: 1, Removed: 1, Changed: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	message := GitCommitMessage("terraformer import aws", map[string]ChangeSummary{"vpc": summary, "subnet": {}})
+	expected := "terraformer import aws\n\nsubnet: 0 added, 0 removed, 0 changed\nvpc: 1 added, 1 removed, 1 changed\n"
+	if message != expected {
+		t.Errorf("got %q, want %q", message, expected)
+	}
+}
+
+func TestSummarizeChangesV4(t *testing.T) {
+	vpc := terraformutils.NewResource("vpc-1", "a", "aws_vpc", "aws", map[string]string{"id": "vpc-1", "cidr_block": "10.0.0.0/16"}, []string{}, map[string]interface{}{})
+	state, err := terraformutils.PrintTfStateV4([]terraformutils.Resource{vpc}, map[string]string{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	if err := ioutil.WriteFile(path, state, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := terraformutils.ReadStateResources(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := SummarizeChanges(previous, []terraformutils.Resource{vpc}); summary != (ChangeSummary{}) {
+		t.Errorf("unchanged resource counted: %+v", summary)
+	}
+	changed := terraformutils.NewResource("vpc-1", "a", "aws_vpc", "aws", map[string]string{"id": "vpc-1", "cidr_block": "10.1.0.0/16"}, []string{}, map[string]interface{}{})
+	if summary := SummarizeChanges(previous, []terraformutils.Resource{changed}); summary != (ChangeSummary{Changed: 1}) {
+		t.Errorf("changed attribute not counted: %+v", summary)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -25,36 +26,109 @@ import (
 	"github.com/hashicorp/terraform/terraform"
 )
 
-func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, path string, serviceName string, isCompact bool, output string) error {
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, path string, serviceName string, isCompact bool, output string, documents string) error {
+	if err := OutputProviderFile(provider, path, output); err != nil {
 		return err
 	}
-	// create provider file
+	return OutputResourceFiles(resources, provider, path, serviceName, isCompact, output, documents)
+}
+
+func ProviderFileData(provider terraformutils.ProviderGenerator) map[string]interface{} {
 	providerData := provider.GetProviderData()
+	requiredProvider := map[string]interface{}{
+		"version": providerwrapper.GetProviderVersion(provider.GetName()),
+	}
+	// without source terraform init looks for hashicorp/<name>, which fails for third-party providers
+	if address, err := providerwrapper.GetProviderAddress(provider.GetName()); err == nil && address.Source() != "" {
+		requiredProvider["source"] = address.Source()
+	}
 	providerData["terraform"] = map[string]interface{}{
 		"required_providers": []map[string]interface{}{{
-			provider.GetName(): map[string]interface{}{
-				"version": providerwrapper.GetProviderVersion(provider.GetName()),
-			},
+			provider.GetName(): requiredProvider,
 		}},
 	}
+	return providerData
+}
 
-	providerDataFile, err := terraformutils.Print(providerData, map[string]struct{}{}, output)
+func OutputProviderFile(provider terraformutils.ProviderGenerator, path string, output string) error {
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+	providerDataFile, err := terraformutils.Print(ProviderFileData(provider), map[string]struct{}{}, output)
 	if err != nil {
 		return err
 	}
 	PrintFile(path+"/provider."+GetFileExtension(output), providerDataFile)
+	return nil
+}
 
+// OutputResourceFiles writes resources and outputs. documents selects how embedded
+// JSON and YAML documents are written, see DocumentsString, DocumentsJSONEncode and DocumentsFile.
+func OutputResourceFiles(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, path string, serviceName string, isCompact bool, output string, documents string) error {
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
 	// create outputs files
-	outputs := map[string]interface{}{}
-	outputsByResource := map[string]map[string]interface{}{}
+	outputsByResource := resourceOutputs(resources, provider, serviceName)
+	if len(outputsByResource) > 0 {
+		outputsFile, err := terraformutils.Print(map[string]interface{}{"output": outputsByResource}, map[string]struct{}{}, output)
+		if err != nil {
+			return err
+		}
+		PrintFile(path+"/outputs."+GetFileExtension(output), outputsFile)
+	}
+
+	// group by resource by type
+	typeOfServices := map[string][]terraformutils.Resource{}
+	for _, r := range resources {
+		typeOfServices[r.InstanceInfo.Type] = append(typeOfServices[r.InstanceInfo.Type], r)
+	}
+	// types with the same file name keep their provider prefix, whatever order they come in
+	fileNames := map[string]struct{}{}
+	if isCompact {
+		err := printFile(resources, "resources", path, output, documents)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, k := range sortedTypes(typeOfServices) {
+			fileName := resourceFileName(k)
+			if _, exist := fileNames[fileName]; exist {
+				fileName = k
+			}
+			fileNames[fileName] = struct{}{}
+			err := printFile(typeOfServices[k], fileName, path, output, documents)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
+func sortedTypes(typeOfServices map[string][]terraformutils.Resource) []string {
+	types := make([]string, 0, len(typeOfServices))
+	for k := range typeOfServices {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// resourceFileName is the file of a resource type, without provider prefix
+func resourceFileName(resourceType string) string {
+	return strings.ReplaceAll(resourceType, strings.Split(resourceType, "_")[0]+"_", "")
+}
+
+// resourceOutputs sets the output states of the resources and returns their output blocks
+func resourceOutputs(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, serviceName string) map[string]map[string]interface{} {
+	outputsByResource := map[string]map[string]interface{}{}
 	for i, r := range resources {
 		outputState := map[string]*terraform.OutputState{}
-		outputsByResource[r.InstanceInfo.Type+"_"+r.ResourceName+"_"+r.GetIDKey()] = map[string]interface{}{
-			"value": "${" + r.InstanceInfo.Type + "." + r.ResourceName + "." + r.GetIDKey() + "}",
+		outputsByResource[terraformutils.OutputName(r, r.GetIDKey())] = map[string]interface{}{
+			"value": "${" + r.Address() + "." + r.GetIDKey() + "}",
 		}
-		outputState[r.InstanceInfo.Type+"_"+r.ResourceName+"_"+r.GetIDKey()] = &terraform.OutputState{
+		outputState[terraformutils.OutputName(r, r.GetIDKey())] = &terraform.OutputState{
 			Type:  "string",
 			Value: r.InstanceState.Attributes[r.GetIDKey()],
 		}
@@ -66,9 +140,9 @@ func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils
 						if ids[1] == "self_link" || ids[1] == "id" {
 							key = r.GetIDKey()
 						}
-						linkKey := r.InstanceInfo.Type + "_" + r.ResourceName + "_" + key
+						linkKey := terraformutils.OutputName(r, key)
 						outputsByResource[linkKey] = map[string]interface{}{
-							"value": "${" + r.InstanceInfo.Type + "." + r.ResourceName + "." + key + "}",
+							"value": "${" + r.Address() + "." + key + "}",
 						}
 						outputState[linkKey] = &terraform.OutputState{
 							Type:  "string",
@@ -80,63 +154,44 @@ func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils
 		}
 		resources[i].Outputs = outputState
 	}
-	if len(outputsByResource) > 0 {
-		outputs["output"] = outputsByResource
-		outputsFile, err := terraformutils.Print(outputs, map[string]struct{}{}, output)
-		if err != nil {
-			return err
-		}
-		PrintFile(path+"/outputs."+GetFileExtension(output), outputsFile)
-	}
+	return outputsByResource
+}
 
-	// group by resource by type
-	typeOfServices := map[string][]terraformutils.Resource{}
-	for _, r := range resources {
-		typeOfServices[r.InstanceInfo.Type] = append(typeOfServices[r.InstanceInfo.Type], r)
-	}
-	if isCompact {
-		err := printFile(resources, "resources", path, output)
-		if err != nil {
-			return err
-		}
-	} else {
-		for k, v := range typeOfServices {
-			fileName := strings.ReplaceAll(k, strings.Split(k, "_")[0]+"_", "")
-			err := printFile(v, fileName, path, output)
-			if err != nil {
-				return err
-			}
-		}
+func printFile(v []terraformutils.Resource, fileName, path, output, documents string) error {
+	tfFile, err := renderFile(v, path, output, documents)
+	if err != nil {
+		return err
 	}
-	return nil
+	return ioutil.WriteFile(path+"/"+fileName+"."+GetFileExtension(output), tfFile, os.ModePerm)
 }
 
-func printFile(v []terraformutils.Resource, fileName, path, output string) error {
+// renderFile prints resources and writes the documents they refer to into the data directory
+func renderFile(v []terraformutils.Resource, path, output, documents string) ([]byte, error) {
+	renderer := newDocumentRenderer(documents, output)
+	v = renderer.Resources(v)
+	dataFiles := []map[string][]byte{renderer.DataFiles()}
 	for _, res := range v {
-		if res.DataFiles == nil {
-			continue
+		if res.DataFiles != nil {
+			dataFiles = append(dataFiles, res.DataFiles)
 		}
-		for fileName, content := range res.DataFiles {
+	}
+	for _, files := range dataFiles {
+		for fileName, content := range files {
 			if err := os.MkdirAll(path+"/data/", os.ModePerm); err != nil {
-				return err
+				return nil, err
 			}
 			err := ioutil.WriteFile(path+"/data/"+fileName, content, os.ModePerm)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
 
 	tfFile, err := terraformutils.HclPrintResource(v, map[string]interface{}{}, output)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = ioutil.WriteFile(path+"/"+fileName+"."+GetFileExtension(output), tfFile, os.ModePerm)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return renderer.Replace(tfFile), nil
 }
 
 func PrintFile(path string, data []byte) {
@@ -0,0 +1,198 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+
+	_ "modernc.org/sqlite" // pure Go driver, release binaries are cross compiled without cgo
+)
+
+var InventoryFormats = []string{"csv", "json", "sqlite"}
+
+// InventoryRecord is one discovered resource of an inventory
+type InventoryRecord struct {
+	Provider   string            `json:"provider"`
+	Service    string            `json:"service"`
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Region     string            `json:"region,omitempty"`
+	Project    string            `json:"project,omitempty"` // project, account or subscription
+	Tags       map[string]string `json:"tags,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// NewInventoryRecord reads tags and the selected attributes from the flat attributes of the resource,
+// the name is the name attribute, the Name tag or the resource name
+func NewInventoryRecord(r terraformutils.Resource, service string, pathAttributes map[string]string, attributes []string) InventoryRecord {
+	record := InventoryRecord{
+		Provider: r.Provider,
+		Service:  service,
+		Type:     r.InstanceInfo.Type,
+		ID:       r.InstanceState.ID,
+		Region:   pathAttributes["region"],
+		Project:  pathAttributes["project"],
+		Tags:     map[string]string{},
+	}
+	if record.Project == "" {
+		record.Project = pathAttributes["account"]
+	}
+	for key, value := range r.InstanceState.Attributes {
+		for _, prefix := range []string{"tags.", "labels."} {
+			if strings.HasPrefix(key, prefix) && key != prefix+"%" {
+				record.Tags[strings.TrimPrefix(key, prefix)] = value
+			}
+		}
+	}
+	if len(record.Tags) == 0 {
+		record.Tags = nil
+	}
+	for _, attribute := range attributes {
+		if value, exist := r.InstanceState.Attributes[attribute]; exist {
+			if record.Attributes == nil {
+				record.Attributes = map[string]string{}
+			}
+			record.Attributes[attribute] = value
+		}
+	}
+	switch {
+	case r.InstanceState.Attributes["name"] != "":
+		record.Name = r.InstanceState.Attributes["name"]
+	case record.Tags["Name"] != "":
+		record.Name = record.Tags["Name"]
+	default:
+		record.Name = r.ResourceName
+	}
+	return record
+}
+
+// SortInventory orders records by provider, service, type and ID
+func SortInventory(records []InventoryRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID < b.ID
+	})
+}
+
+// WriteInventoryCSV writes a row per record, tags as JSON object and a column per selected attribute
+func WriteInventoryCSV(w io.Writer, records []InventoryRecord, attributes []string) error {
+	writer := csv.NewWriter(w)
+	header := []string{"provider", "service", "type", "id", "name", "region", "project", "tags"}
+	if err := writer.Write(append(header, attributes...)); err != nil {
+		return err
+	}
+	for _, r := range records {
+		tags := ""
+		if len(r.Tags) > 0 {
+			data, err := json.Marshal(r.Tags)
+			if err != nil {
+				return err
+			}
+			tags = string(data)
+		}
+		row := []string{r.Provider, r.Service, r.Type, r.ID, r.Name, r.Region, r.Project, tags}
+		for _, attribute := range attributes {
+			row = append(row, r.Attributes[attribute])
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func WriteInventoryJSON(w io.Writer, records []InventoryRecord) error {
+	if records == nil {
+		records = []InventoryRecord{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// WriteInventorySQLite replaces the database at path with a resources table,
+// tags and attributes are JSON columns queried with the json functions of SQLite
+func WriteInventorySQLite(path string, records []InventoryRecord) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`CREATE TABLE resources (
+	provider TEXT NOT NULL,
+	service TEXT NOT NULL,
+	type TEXT NOT NULL,
+	id TEXT NOT NULL,
+	name TEXT,
+	region TEXT,
+	project TEXT,
+	tags TEXT,
+	attributes TEXT
+)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	insert, err := tx.Prepare(`INSERT INTO resources VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer insert.Close()
+	for _, r := range records {
+		tags, err := json.Marshal(r.Tags)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		attributes, err := json.Marshal(r.Attributes)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := insert.Exec(r.Provider, r.Service, r.Type, r.ID, r.Name, r.Region, r.Project, string(tags), string(attributes)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("inventory: can't insert %s %s: %w", r.Type, r.ID, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func testInventory() []InventoryRecord {
+	vpc := terraformutils.NewResource("vpc-1", "tfer--vpc-1", "aws_vpc", "aws", map[string]string{
+		"cidr_block": "10.0.0.0/16",
+		"tags.%":     "2",
+		"tags.Name":  "main",
+		"tags.team":  "network",
+	}, []string{}, map[string]interface{}{})
+	bucket := terraformutils.NewResource("logs", "tfer--logs", "aws_s3_bucket", "aws", map[string]string{
+		"name": "logs",
+	}, []string{}, map[string]interface{}{})
+	path := map[string]string{"region": "eu-west-1", "account": "123456789012"}
+	records := []InventoryRecord{
+		NewInventoryRecord(vpc, "vpc", path, []string{"cidr_block"}),
+		NewInventoryRecord(bucket, "s3", path, []string{"cidr_block"}),
+	}
+	SortInventory(records)
+	return records
+}
+
+func TestInventoryRecord(t *testing.T) {
+	records := testInventory()
+	expected := []InventoryRecord{
+		{Provider: "aws", Service: "s3", Type: "aws_s3_bucket", ID: "logs", Name: "logs", Region: "eu-west-1", Project: "123456789012"},
+		{
+			Provider: "aws", Service: "vpc", Type: "aws_vpc", ID: "vpc-1", Name: "main", Region: "eu-west-1", Project: "123456789012",
+			Tags:       map[string]string{"Name": "main", "team": "network"},
+			Attributes: map[string]string{"cidr_block": "10.0.0.0/16"},
+		},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("got %+v, want %+v", records, expected)
+	}
+}
+
+func TestWriteInventoryCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteInventoryCSV(&buf, testInventory(), []string{"cidr_block"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `provider,service,type,id,name,region,project,tags,cidr_block
+aws,s3,aws_s3_bucket,logs,logs,eu-west-1,123456789012,,
+aws,vpc,aws_vpc,vpc-1,main,eu-west-1,123456789012,"{""Name"":""main"",""team"":""network""}",10.0.0.0/16
+`
+	if buf.String() != expected {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteInventorySQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.db")
+	for i := 0; i < 2; i++ { // an existing database is replaced
+		if err := WriteInventorySQLite(path, testInventory()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM resources`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("got %d resources, want 2", count)
+	}
+	var id string
+	if err := db.QueryRow(`SELECT id FROM resources WHERE json_extract(tags, '$.team') = 'network'`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if id != "vpc-1" {
+		t.Errorf("got %s, want vpc-1", id)
+	}
+}
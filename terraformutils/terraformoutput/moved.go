@@ -0,0 +1,122 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+type Moved struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PreviousNames indexes resource names of a previous import by type and ID
+type PreviousNames map[string]string
+
+func NewPreviousNames(previous []terraformutils.StateResource) PreviousNames {
+	names := PreviousNames{}
+	for _, r := range previous {
+		key := previousNameKey(r.Type, r.ID)
+		if _, exist := names[key]; !exist {
+			names[key] = r.Name
+		}
+	}
+	return names
+}
+
+func previousNameKey(resourceType, id string) string {
+	return resourceType + "/" + id
+}
+
+func (p PreviousNames) Lookup(r terraformutils.Resource) (string, bool) {
+	name, exist := p[previousNameKey(r.InstanceInfo.Type, r.InstanceState.ID)]
+	return name, exist
+}
+
+// KeepPreviousNames renames resources back to the name they had in the previous
+// import, unless the name is taken by another resource of the same type.
+func (p PreviousNames) KeepPreviousNames(resources []terraformutils.Resource) {
+	taken := map[string]bool{}
+	for _, r := range resources {
+		taken[r.InstanceInfo.Type+"."+r.ResourceName] = true
+	}
+	for i, r := range resources {
+		name, exist := p.Lookup(r)
+		if !exist || name == r.ResourceName || taken[r.InstanceInfo.Type+"."+name] {
+			continue
+		}
+		log.Printf("keep previous name %s.%s for %s.%s", r.InstanceInfo.Type, name, r.InstanceInfo.Type, r.ResourceName)
+		delete(taken, r.InstanceInfo.Type+"."+r.ResourceName)
+		taken[r.InstanceInfo.Type+"."+name] = true
+		resources[i].ResourceName = name
+		resources[i].InstanceInfo.Id = fmt.Sprintf("%s.%s", r.InstanceInfo.Type, name)
+	}
+}
+
+// MovedBlocks returns moved blocks for resources with a different address in the previous import.
+// Previous addresses which are still declared can't be moved.
+func (p PreviousNames) MovedBlocks(resources []terraformutils.Resource) []Moved {
+	declared := map[string]bool{}
+	for _, r := range resources {
+		declared[r.InstanceInfo.Type+"."+r.ResourceName] = true
+	}
+	var moved []Moved
+	for _, r := range resources {
+		name, exist := p.Lookup(r)
+		if !exist || name == r.ResourceName || declared[r.InstanceInfo.Type+"."+name] || r.DataSource {
+			continue
+		}
+		moved = append(moved, Moved{
+			From: r.InstanceInfo.Type + "." + name,
+			To:   r.InstanceInfo.Type + "." + r.ResourceName,
+		})
+	}
+	sort.Slice(moved, func(i, j int) bool {
+		return moved[i].To < moved[j].To
+	})
+	return moved
+}
+
+func OutputMovedFile(moved []Moved, path string, output string) error {
+	if len(moved) == 0 {
+		return nil
+	}
+	if output == "json" {
+		movedFile, err := terraformutils.Print(map[string]interface{}{"moved": moved}, map[string]struct{}{}, output)
+		if err != nil {
+			return err
+		}
+		PrintFile(path+"/moved."+GetFileExtension(output), movedFile)
+		return nil
+	}
+	f := hclwrite.NewEmptyFile()
+	for i, m := range moved {
+		if i > 0 {
+			f.Body().AppendNewline()
+		}
+		block := f.Body().AppendNewBlock("moved", nil)
+		block.Body().SetAttributeRaw("from", rawTokens(m.From))
+		block.Body().SetAttributeRaw("to", rawTokens(m.To))
+	}
+	PrintFile(path+"/moved."+GetFileExtension(output), f.Bytes())
+	return nil
+}
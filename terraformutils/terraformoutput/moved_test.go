@@ -0,0 +1,52 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func TestPreviousNames(t *testing.T) {
+	previous := NewPreviousNames([]terraformutils.StateResource{
+		{Type: "aws_vpc", Name: "tfer--old", ID: "vpc-1"},
+		{Type: "aws_vpc", Name: "tfer--taken", ID: "vpc-2"},
+		{Type: "aws_subnet", Name: "tfer--same", ID: "subnet-1"},
+	})
+	resources := []terraformutils.Resource{
+		terraformutils.NewSimpleResource("vpc-1", "new", "aws_vpc", "aws", []string{}),
+		terraformutils.NewSimpleResource("vpc-2", "other", "aws_vpc", "aws", []string{}),
+		terraformutils.NewSimpleResource("vpc-3", "taken", "aws_vpc", "aws", []string{}),
+		terraformutils.NewSimpleResource("subnet-1", "same", "aws_subnet", "aws", []string{}),
+	}
+
+	moved := previous.MovedBlocks(resources)
+	expected := []Moved{
+		{From: "aws_vpc.tfer--old", To: "aws_vpc.tfer--new"},
+	}
+	if !reflect.DeepEqual(moved, expected) {
+		t.Errorf("unexpected moved blocks %v", moved)
+	}
+
+	previous.KeepPreviousNames(resources)
+	if resources[0].ResourceName != "tfer--old" || resources[0].InstanceInfo.Id != "aws_vpc.tfer--old" {
+		t.Errorf("previous name not kept %v", resources[0].InstanceInfo)
+	}
+	if resources[1].ResourceName != "tfer--other" {
+		t.Errorf("taken name should not be reused %v", resources[1].InstanceInfo)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const TerragruntFileName = "terragrunt.hcl"
+
+var terragruntVariableRe = regexp.MustCompile(`\$\{var\.([\w-]+)}`)
+
+// TerragruntReference links resources through module variables, which are
+// fed by terragrunt dependency blocks. Resources of the same directory and
+// data sources are referenced directly.
+func TerragruntReference(service string, resource terraformutils.Resource, key string) string {
+	if service == "local" || resource.DataSource {
+		return "${" + resource.Address() + "." + key + "}"
+	}
+	return "${var." + terraformutils.OutputName(resource, key) + "}"
+}
+
+type TerragruntDependency struct {
+	Name        string
+	ConfigPath  string
+	MockOutputs map[string]string
+}
+
+type TerragruntConfig struct {
+	IncludeRoot   bool
+	ProviderFile  []byte
+	Backend       string
+	BackendConfig map[string]string
+	Dependencies  []TerragruntDependency
+}
+
+// TerragruntDependencies resolves the variables referenced by resources to the
+// services exporting them.
+func TerragruntDependencies(resources []terraformutils.Resource, dependencies map[string][]terraformutils.Resource, configPath func(service string) string) []TerragruntDependency {
+	variables := map[string]bool{}
+	for _, r := range resources {
+		collectTerragruntVariables(r.Item, variables)
+	}
+	var services []string
+	for service := range dependencies {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var result []TerragruntDependency
+	for _, service := range services {
+		mockOutputs := map[string]string{}
+		for _, r := range dependencies[service] {
+			prefix := terraformutils.OutputName(r, "")
+			for variable := range variables {
+				if !strings.HasPrefix(variable, prefix) {
+					continue
+				}
+				if value, exist := r.InstanceState.Attributes[strings.TrimPrefix(variable, prefix)]; exist {
+					mockOutputs[variable] = value
+				}
+			}
+		}
+		if len(mockOutputs) > 0 {
+			result = append(result, TerragruntDependency{
+				Name:        service,
+				ConfigPath:  configPath(service),
+				MockOutputs: mockOutputs,
+			})
+		}
+	}
+	return result
+}
+
+func collectTerragruntVariables(item interface{}, variables map[string]bool) {
+	switch t := item.(type) {
+	case string:
+		for _, match := range terragruntVariableRe.FindAllStringSubmatch(t, -1) {
+			variables[match[1]] = true
+		}
+	case map[string]interface{}:
+		for _, v := range t {
+			collectTerragruntVariables(v, variables)
+		}
+	case []interface{}:
+		for _, v := range t {
+			collectTerragruntVariables(v, variables)
+		}
+	case []map[string]interface{}:
+		for _, v := range t {
+			collectTerragruntVariables(v, variables)
+		}
+	}
+}
+
+// Variables returns the module variables declaration for dependency outputs.
+func (c TerragruntConfig) Variables() map[string]interface{} {
+	variables := map[string]interface{}{}
+	for _, dependency := range c.Dependencies {
+		for name := range dependency.MockOutputs {
+			variables[name] = map[string]interface{}{}
+		}
+	}
+	return map[string]interface{}{"variable": variables}
+}
+
+func (c TerragruntConfig) Bytes() []byte {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	if c.IncludeRoot {
+		include := body.AppendNewBlock("include", []string{"root"})
+		include.Body().SetAttributeRaw("path", rawTokens("find_in_parent_folders()"))
+		body.AppendNewline()
+	}
+	if c.ProviderFile != nil {
+		generate := body.AppendNewBlock("generate", []string{"provider"})
+		generate.Body().SetAttributeValue("path", cty.StringVal("provider.tf"))
+		generate.Body().SetAttributeValue("if_exists", cty.StringVal("overwrite_terragrunt"))
+		generate.Body().SetAttributeRaw("contents", heredocTokens(string(c.ProviderFile)))
+		body.AppendNewline()
+	}
+	if c.Backend != "" {
+		remoteState := body.AppendNewBlock("remote_state", nil)
+		remoteState.Body().SetAttributeValue("backend", cty.StringVal(c.Backend))
+		remoteState.Body().SetAttributeValue("generate", cty.ObjectVal(map[string]cty.Value{
+			"path":      cty.StringVal("backend.tf"),
+			"if_exists": cty.StringVal("overwrite_terragrunt"),
+		}))
+		if c.Backend == "local" {
+			remoteState.Body().SetAttributeRaw("config", rawTokens(`{ path = "${get_terragrunt_dir()}/terraform.tfstate" }`))
+		} else {
+			config := map[string]cty.Value{}
+			for k, v := range c.BackendConfig {
+				config[k] = cty.StringVal(v)
+			}
+			remoteState.Body().SetAttributeValue("config", cty.ObjectVal(config))
+		}
+	}
+	if len(c.Dependencies) == 0 {
+		return f.Bytes()
+	}
+	inputs := map[string]string{}
+	for _, dependency := range c.Dependencies {
+		body.AppendNewline()
+		block := body.AppendNewBlock("dependency", []string{dependency.Name})
+		block.Body().SetAttributeValue("config_path", cty.StringVal(dependency.ConfigPath))
+		mockOutputs := map[string]cty.Value{}
+		for name, value := range dependency.MockOutputs {
+			mockOutputs[name] = cty.StringVal(value)
+			inputs[name] = "dependency." + dependency.Name + ".outputs." + name
+		}
+		block.Body().SetAttributeValue("mock_outputs", cty.ObjectVal(mockOutputs))
+	}
+	body.AppendNewline()
+	body.SetAttributeRaw("inputs", objectTokens(inputs))
+	return f.Bytes()
+}
+
+func rawTokens(expr string) hclwrite.Tokens {
+	return hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(expr)}}
+}
+
+func heredocTokens(content string) hclwrite.Tokens {
+	content = strings.ReplaceAll(content, "${", "$${")
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return hclwrite.Tokens{
+		{Type: hclsyntax.TokenOHeredoc, Bytes: []byte("<<EOF\n")},
+		{Type: hclsyntax.TokenStringLit, Bytes: []byte(content)},
+		{Type: hclsyntax.TokenCHeredoc, Bytes: []byte("EOF")},
+	}
+}
+
+func objectTokens(attributes map[string]string) hclwrite.Tokens {
+	var names []string
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")},
+		{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+	for _, name := range names {
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(name)},
+			&hclwrite.Token{Type: hclsyntax.TokenEqual, Bytes: []byte("=")},
+			&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(attributes[name])},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestTerragruntDependencies(t *testing.T) {
+	vpc := terraformutils.NewSimpleResource("vpc-1", "main", "aws_vpc", "aws", []string{})
+	vpc.InstanceState.Attributes["id"] = "vpc-1"
+	subnet := terraformutils.NewSimpleResource("subnet-1", "main", "aws_subnet", "aws", []string{})
+	subnet.Item = map[string]interface{}{
+		"vpc_id": TerragruntReference("vpc", vpc, "id"),
+	}
+
+	dependencies := TerragruntDependencies([]terraformutils.Resource{subnet},
+		map[string][]terraformutils.Resource{"vpc": {vpc}, "sg": {}},
+		func(service string) string { return "../" + service })
+	if len(dependencies) != 1 {
+		t.Fatalf("expected 1 dependency, got %v", dependencies)
+	}
+	if dependencies[0].ConfigPath != "../vpc" || dependencies[0].MockOutputs["aws_vpc_tfer--main_id"] != "vpc-1" {
+		t.Errorf("unexpected dependency %v", dependencies[0])
+	}
+
+	config := TerragruntConfig{
+		IncludeRoot:  true,
+		Backend:      "local",
+		Dependencies: dependencies,
+	}
+	data := config.Bytes()
+	if _, diags := hclsyntax.ParseConfig(data, TerragruntFileName, hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		t.Fatalf("invalid terragrunt config %s: %s", data, diags.Error())
+	}
+	if !strings.Contains(string(data), "aws_vpc_tfer--main_id = dependency.vpc.outputs.aws_vpc_tfer--main_id") {
+		t.Errorf("missing dependency input in %s", data)
+	}
+}
+
+func TestTerragruntProviderGeneration(t *testing.T) {
+	config := TerragruntConfig{ProviderFile: []byte("provider \"aws\" {\n  region = \"${var.region}\"\n}\n")}
+	data := config.Bytes()
+	if _, diags := hclsyntax.ParseConfig(data, TerragruntFileName, hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		t.Fatalf("invalid terragrunt config %s: %s", data, diags.Error())
+	}
+	if !strings.Contains(string(data), "$${var.region}") {
+		t.Errorf("template sequences should be escaped in %s", data)
+	}
+}
@@ -16,11 +16,16 @@ package terraformutils
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
 
+	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -28,12 +33,16 @@ type BaseResource struct {
 	Tags map[string]string `json:"tags,omitempty"`
 }
 
+// StateSerial is the serial of generated states, every import writes a new state
+const StateSerial = 1
+
 func NewTfState(resources []Resource) *terraform.State {
 	tfstate := &terraform.State{
 		Version:   terraform.StateVersion,
 		TFVersion: terraform.VersionString(), //nolint
-		Serial:    1,
+		Serial:    StateSerial,
 	}
+	resources = uniqueResources(resources)
 	outputs := map[string]*terraform.OutputState{}
 	for _, r := range resources {
 		for k, v := range r.Outputs {
@@ -48,23 +57,113 @@ func NewTfState(resources []Resource) *terraform.State {
 		},
 	}
 	for _, resource := range resources {
+		if resource.DataSource {
+			continue
+		}
+		provider := "provider." + resource.Provider
+		if resource.ProviderAlias != "" {
+			provider += "." + resource.ProviderAlias
+		}
 		resourceState := &terraform.ResourceState{
 			Type:     resource.InstanceInfo.Type,
 			Primary:  resource.InstanceState,
-			Provider: "provider." + resource.Provider,
+			Provider: provider,
 		}
 		tfstate.Modules[0].Resources[resource.InstanceInfo.Type+"."+resource.ResourceName] = resourceState
 	}
 	return tfstate
 }
 
-func PrintTfState(resources []Resource) ([]byte, error) {
+// uniqueResources keeps the resource with the lowest ID of resources with the same address, like
+// HclPrintResource does, so states don't depend on the order of resources
+func uniqueResources(resources []Resource) []Resource {
+	unique := make([]Resource, 0, len(resources))
+	indexes := map[string]int{}
+	for _, r := range resources {
+		i, exist := indexes[r.Address()]
+		if !exist {
+			indexes[r.Address()] = len(unique)
+			unique = append(unique, r)
+		} else if r.InstanceState.ID < unique[i].InstanceState.ID {
+			unique[i] = r
+		}
+	}
+	return unique
+}
+
+// StableLineage is a state lineage derived from name, it is the same on every import of name
+func StableLineage(name string) string {
+	// formatted like the random lineages of go-uuid
+	sum := sha256.Sum256([]byte("terraformer:" + name))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// PrintTfState writes the state in the legacy format, lineage is a new one when it's empty
+func PrintTfState(resources []Resource, lineage string) ([]byte, error) {
 	state := NewTfState(resources)
+	state.Lineage = lineage
 	var buf bytes.Buffer
 	err := terraform.WriteState(state, &buf)
 	return buf.Bytes(), err
 }
 
+// PrintTfStateV4 writes the state in the version 4 format, with provider addresses like
+// provider["registry.opentofu.org/hashicorp/aws"]. Terraform and OpenTofu read provider.aws of the
+// legacy format as a provider of their default registry. providerSources are the full source
+// addresses by provider name, resources of other providers keep legacy addresses
+func PrintTfStateV4(resources []Resource, providerSources map[string]string, lineage string) ([]byte, error) {
+	legacy, err := PrintTfState(resources, lineage)
+	if err != nil {
+		return nil, err
+	}
+	file, err := statefile.Read(bytes.NewReader(legacy))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := statefile.Write(file, &buf); err != nil {
+		return nil, err
+	}
+	// same fields as statefile writes them, in the same order
+	var state struct {
+		Version          json.RawMessage `json:"version"`
+		TerraformVersion string          `json:"terraform_version"`
+		Serial           uint64          `json:"serial"`
+		Lineage          string          `json:"lineage"`
+		Outputs          json.RawMessage `json:"outputs"`
+		Resources        []struct {
+			Module    string          `json:"module,omitempty"`
+			Mode      string          `json:"mode"`
+			Type      string          `json:"type"`
+			Name      string          `json:"name"`
+			EachMode  string          `json:"each,omitempty"`
+			Provider  string          `json:"provider"`
+			Instances json.RawMessage `json:"instances"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+		return nil, err
+	}
+	for i, r := range state.Resources {
+		// provider.<name> or provider.<name>.<alias>
+		parts := strings.SplitN(strings.TrimPrefix(r.Provider, "provider."), ".", 2)
+		source, ok := providerSources[parts[0]]
+		if !ok {
+			continue
+		}
+		state.Resources[i].Provider = `provider["` + source + `"]`
+		if len(parts) == 2 {
+			state.Resources[i].Provider += "." + parts[1]
+		}
+	}
+	buf.Reset()
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(&state)
+	return buf.Bytes(), err
+}
+
 func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderWrapper, slowProcessingResources [][]*Resource) ([]*Resource, error) {
 	refreshedResources := []*Resource{}
 	input := make(chan *Resource, len(resources))
@@ -95,11 +194,14 @@ func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderW
 	}
 
 	wg.Wait()
+	refreshed, failed := map[string]int{}, map[string]int{}
 	for _, r := range resources {
 		if r.InstanceState != nil && r.InstanceState.ID != "" {
 			refreshedResources = append(refreshedResources, r)
+			refreshed[r.Provider]++
 		} else {
 			log.Printf("ERROR: Unable to refresh resource %s", r.ResourceName)
+			failed[r.Provider]++
 		}
 	}
 
@@ -108,11 +210,19 @@ func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderW
 			r := resourceGroup[i]
 			if r.InstanceState != nil && r.InstanceState.ID != "" {
 				refreshedResources = append(refreshedResources, r)
+				refreshed[r.Provider]++
 			} else {
 				log.Printf("ERROR: Unable to refresh resource %s", r.ResourceName)
+				failed[r.Provider]++
 			}
 		}
 	}
+	for provider, count := range refreshed {
+		ReportProgress(ProgressRefreshed, provider, "", count)
+	}
+	for provider, count := range failed {
+		ReportProgress(ProgressFailed, provider, "", count)
+	}
 	return refreshedResources, nil
 }
 
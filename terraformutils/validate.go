@@ -0,0 +1,259 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// maxValidatePasses bounds how often a resource is validated again after dropping attributes
+const maxValidatePasses = 3
+
+// Providers built with the legacy SDK report computed attributes without an attribute path
+var cannotBeSetRe = regexp.MustCompile(`"([^"]+)": this field cannot be set|value was set for "([^"]+)"`)
+
+// Validate checks the generated config with the provider. Computed only attributes
+// reported by the provider are removed from Item, remaining errors are returned.
+func (r *Resource) Validate(provider *providerwrapper.ProviderWrapper) (dropped []string, errs []string) {
+	schema, exist := provider.GetSchema().ResourceTypes[r.InstanceInfo.Type]
+	if !exist || r.Item == nil || r.DataSource {
+		return nil, nil
+	}
+	return r.validate(schema.Block, func(config cty.Value) tfdiags.Diagnostics {
+		return provider.ValidateResource(r.InstanceInfo.Type, config)
+	})
+}
+
+func (r *Resource) validate(block *configschema.Block, validate func(cty.Value) tfdiags.Diagnostics) (dropped []string, errs []string) {
+	for pass := 0; pass < maxValidatePasses; pass++ {
+		config, err := ItemValue(r.Item, block.ImpliedType())
+		if err != nil {
+			log.Printf("skip validation of %s: %s", r.InstanceInfo.Id, err)
+			return dropped, nil
+		}
+		errs = nil
+		droppedInPass := false
+		for _, diag := range validate(config) {
+			if diag.Severity() != tfdiags.Error {
+				continue
+			}
+			path := diagnosticPath(diag)
+			if attributePath, ok := computedOnlyPath(block, path); ok && deleteItemPath(r.Item, attributePath) {
+				dropped = append(dropped, strings.Join(attributePath, "."))
+				droppedInPass = true
+				continue
+			}
+			errs = append(errs, formatDiagnostic(path, diag))
+		}
+		if !droppedInPass {
+			break
+		}
+	}
+	return dropped, errs
+}
+
+// ItemValue converts a resource Item into a config value of the given type.
+// Strings holding references are unknown until apply, so they become unknown values.
+func ItemValue(item map[string]interface{}, ty cty.Type) (cty.Value, error) {
+	return itemValue(item, ty, nil)
+}
+
+// childPath copies the path, so paths of sibling attributes don't share the array
+func childPath(path []string, name string) []string {
+	return append(append([]string{}, path...), name)
+}
+
+func itemValue(v interface{}, ty cty.Type, path []string) (cty.Value, error) {
+	if v == nil {
+		return cty.NullVal(ty), nil
+	}
+	if s, ok := v.(string); ok && strings.Contains(s, "${") {
+		return cty.UnknownVal(ty), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case ty == cty.DynamicPseudoType:
+		return cty.UnknownVal(ty), nil
+	case ty.IsPrimitiveType():
+		if rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice {
+			return cty.NilVal, fmt.Errorf("%s: %s is required", strings.Join(path, "."), ty.FriendlyName())
+		}
+		return convert.Convert(cty.StringVal(fmt.Sprint(v)), ty)
+	case ty.IsObjectType():
+		if rv.Kind() == reflect.Slice {
+			// single nested blocks are kept as a list of one element
+			if rv.Len() == 0 {
+				return cty.NullVal(ty), nil
+			}
+			rv = rv.Index(0)
+			v = rv.Interface()
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return cty.NilVal, fmt.Errorf("%s: object is required", strings.Join(path, "."))
+		}
+		attributes := map[string]cty.Value{}
+		for name, attributeType := range ty.AttributeTypes() {
+			value, err := itemValue(m[name], attributeType, childPath(path, name))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attributes[name] = value
+		}
+		return cty.ObjectVal(attributes), nil
+	case ty.IsListType() || ty.IsSetType():
+		if rv.Kind() != reflect.Slice {
+			return cty.NilVal, fmt.Errorf("%s: list is required", strings.Join(path, "."))
+		}
+		var elements []cty.Value
+		for i := 0; i < rv.Len(); i++ {
+			value, err := itemValue(rv.Index(i).Interface(), ty.ElementType(), childPath(path, strconv.Itoa(i)))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elements = append(elements, value)
+		}
+		switch {
+		case len(elements) == 0 && ty.IsListType():
+			return cty.ListValEmpty(ty.ElementType()), nil
+		case len(elements) == 0:
+			return cty.SetValEmpty(ty.ElementType()), nil
+		case ty.IsListType():
+			return cty.ListVal(elements), nil
+		default:
+			return cty.SetVal(elements), nil
+		}
+	case ty.IsMapType():
+		if rv.Kind() != reflect.Map {
+			return cty.NilVal, fmt.Errorf("%s: map is required", strings.Join(path, "."))
+		}
+		elements := map[string]cty.Value{}
+		for _, key := range rv.MapKeys() {
+			k := fmt.Sprint(key.Interface())
+			value, err := itemValue(rv.MapIndex(key).Interface(), ty.ElementType(), childPath(path, k))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elements[k] = value
+		}
+		if len(elements) == 0 {
+			return cty.MapValEmpty(ty.ElementType()), nil
+		}
+		return cty.MapVal(elements), nil
+	default:
+		return cty.NilVal, fmt.Errorf("%s: unsupported type %s", strings.Join(path, "."), ty.FriendlyName())
+	}
+}
+
+// diagnosticPath returns the attribute path of a diagnostic as Item keys
+func diagnosticPath(diag tfdiags.Diagnostic) []string {
+	var path []string
+	for _, step := range tfdiags.GetAttribute(diag) {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			path = append(path, step.Name)
+		case cty.IndexStep:
+			if step.Key.Type() == cty.String {
+				path = append(path, step.Key.AsString())
+			} else if step.Key.Type() == cty.Number {
+				index, _ := step.Key.AsBigFloat().Int64()
+				path = append(path, strconv.FormatInt(index, 10))
+			}
+		}
+	}
+	if len(path) > 0 {
+		return path
+	}
+	description := diag.Description()
+	if match := cannotBeSetRe.FindStringSubmatch(description.Summary + " " + description.Detail); match != nil {
+		return strings.Split(match[1]+match[2], ".")
+	}
+	return nil
+}
+
+// computedOnlyPath returns the path of the attribute addressed by path if the provider computes it
+func computedOnlyPath(block *configschema.Block, path []string) ([]string, bool) {
+	for i := 0; i < len(path); i++ {
+		if attribute, exist := block.Attributes[path[i]]; exist {
+			computedOnly := attribute.Computed && !attribute.Optional && !attribute.Required
+			return path[:i+1], computedOnly
+		}
+		nested, exist := block.BlockTypes[path[i]]
+		if !exist {
+			return nil, false
+		}
+		if nested.Nesting != configschema.NestingSingle && nested.Nesting != configschema.NestingGroup {
+			i++ // skip element index
+		}
+		block = &nested.Block
+	}
+	return nil, false
+}
+
+func deleteItemPath(item map[string]interface{}, path []string) bool {
+	var current interface{} = item
+	for i := 0; i < len(path); i++ {
+		key := path[i]
+		switch value := current.(type) {
+		case map[string]interface{}:
+			if i == len(path)-1 {
+				if _, exist := value[key]; !exist {
+					return false
+				}
+				delete(value, key)
+				return true
+			}
+			current = value[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil && len(value) == 1 {
+				// single nested block without element index
+				current = value[0]
+				i--
+				continue
+			}
+			if err != nil || index >= len(value) {
+				return false
+			}
+			current = value[index]
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func formatDiagnostic(path []string, diag tfdiags.Diagnostic) string {
+	description := diag.Description()
+	message := description.Summary
+	if description.Detail != "" {
+		message += ": " + description.Detail
+	}
+	if len(path) > 0 && !strings.Contains(message, strings.Join(path, ".")) {
+		message = strings.Join(path, ".") + ": " + message
+	}
+	return message
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var validateSchema = &configschema.Block{
+	Attributes: map[string]*configschema.Attribute{
+		"name":  {Type: cty.String, Required: true},
+		"arn":   {Type: cty.String, Computed: true},
+		"port":  {Type: cty.Number, Optional: true},
+		"vpc":   {Type: cty.String, Optional: true},
+		"tags":  {Type: cty.Map(cty.String), Optional: true},
+		"cidrs": {Type: cty.Set(cty.String), Optional: true},
+	},
+	BlockTypes: map[string]*configschema.NestedBlock{
+		"rule": {
+			Nesting: configschema.NestingList,
+			Block: configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"action":  {Type: cty.String, Required: true},
+					"created": {Type: cty.String, Computed: true},
+				},
+			},
+		},
+	},
+}
+
+func TestItemValue(t *testing.T) {
+	item := map[string]interface{}{
+		"name":  "web",
+		"port":  "8080",
+		"vpc":   "${aws_vpc.main.id}",
+		"tags":  map[string]interface{}{"env": "prod"},
+		"cidrs": []interface{}{"10.0.0.0/8"},
+		"rule":  []interface{}{map[string]interface{}{"action": "allow"}},
+		"extra": "not in schema",
+	}
+	value, err := ItemValue(item, validateSchema.ImpliedType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !value.GetAttr("port").RawEquals(cty.NumberIntVal(8080)) {
+		t.Errorf("unexpected port %#v", value.GetAttr("port"))
+	}
+	if value.GetAttr("vpc").IsKnown() {
+		t.Error("references must be unknown")
+	}
+	if !value.GetAttr("arn").IsNull() {
+		t.Error("missing attributes must be null")
+	}
+	if value.GetAttr("rule").LengthInt() != 1 {
+		t.Error("nested blocks must be converted")
+	}
+}
+
+func TestItemValueErrorPath(t *testing.T) {
+	item := map[string]interface{}{
+		"name": "web",
+		"rule": []interface{}{
+			map[string]interface{}{"action": "allow"},
+			map[string]interface{}{"action": []interface{}{"deny"}},
+		},
+	}
+	_, err := ItemValue(item, validateSchema.ImpliedType())
+	if err == nil || !strings.HasPrefix(err.Error(), "rule.1.action:") {
+		t.Errorf("expected an error of rule.1.action, got %v", err)
+	}
+}
+
+func TestChildPath(t *testing.T) {
+	path := make([]string, 1, 4)
+	path[0] = "rule"
+	first := childPath(path, "action")
+	second := childPath(path, "created")
+	if strings.Join(first, ".") != "rule.action" || strings.Join(second, ".") != "rule.created" {
+		t.Errorf("sibling paths share their array: %v %v", first, second)
+	}
+}
+
+func TestValidateDropsComputedAttributes(t *testing.T) {
+	r := NewSimpleResource("sg-1", "web", "aws_security_group", "aws", []string{})
+	r.Item = map[string]interface{}{
+		"name": "web",
+		"arn":  "arn:aws:ec2:::sg-1",
+		"rule": []interface{}{map[string]interface{}{"action": "allow", "created": "yesterday"}},
+	}
+	validate := func(config cty.Value) tfdiags.Diagnostics {
+		var diags tfdiags.Diagnostics
+		if !config.GetAttr("arn").IsNull() {
+			diags = diags.Append(tfdiags.AttributeValue(tfdiags.Error, "Computed attributes cannot be set", "", cty.GetAttrPath("arn")))
+		}
+		if !config.GetAttr("rule").Index(cty.NumberIntVal(0)).GetAttr("created").IsNull() {
+			diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, `"rule.0.created": this field cannot be set`, ""))
+		}
+		diags = diags.Append(tfdiags.AttributeValue(tfdiags.Error, "Conflicting configuration", "", cty.GetAttrPath("name")))
+		return diags
+	}
+
+	dropped, errs := r.validate(validateSchema, validate)
+	if !reflect.DeepEqual(dropped, []string{"arn", "rule.0.created"}) {
+		t.Errorf("unexpected dropped attributes %v", dropped)
+	}
+	if !reflect.DeepEqual(errs, []string{"name: Conflicting configuration"}) {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	expected := map[string]interface{}{
+		"name": "web",
+		"rule": []interface{}{map[string]interface{}{"action": "allow"}},
+	}
+	if !reflect.DeepEqual(r.Item, expected) {
+		t.Errorf("unexpected item %v", r.Item)
+	}
+}
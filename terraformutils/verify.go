@@ -0,0 +1,122 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PlanDiff plans the generated config against the refreshed state and returns
+// the top level attributes which would still change after import.
+func (r *Resource) PlanDiff(provider *providerwrapper.ProviderWrapper) ([]string, error) {
+	schema, exist := provider.GetSchema().ResourceTypes[r.InstanceInfo.Type]
+	if !exist || r.Item == nil || r.DataSource {
+		return nil, nil
+	}
+	impliedType := schema.Block.ImpliedType()
+	priorState, err := r.InstanceState.AttrsAsObjectValue(impliedType)
+	if err != nil {
+		return nil, err
+	}
+	config, err := ItemValue(r.Item, impliedType)
+	if err != nil {
+		return nil, err
+	}
+	plannedState, diags := provider.PlanResource(r.InstanceInfo.Type, priorState, config)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+	if plannedState.IsNull() {
+		return nil, fmt.Errorf("planned state of %s is null", r.InstanceInfo.Id)
+	}
+	return changedAttributes(schema.Block, priorState, plannedState), nil
+}
+
+// changedAttributes compares prior and planned state by top level attributes and blocks.
+// Values depending on references are unknown and computed only attributes follow other
+// changes, so both are skipped.
+func changedAttributes(block *configschema.Block, prior, planned cty.Value) []string {
+	var names []string
+	for name, attribute := range block.Attributes {
+		if attribute.Computed && !attribute.Optional && !attribute.Required {
+			continue
+		}
+		names = append(names, name)
+	}
+	for name := range block.BlockTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changed []string
+	for _, name := range names {
+		priorValue := prior.GetAttr(name)
+		plannedValue := planned.GetAttr(name)
+		if !plannedValue.IsWhollyKnown() || !priorValue.IsWhollyKnown() {
+			continue
+		}
+		if isNullOrEmpty(priorValue) && isNullOrEmpty(plannedValue) {
+			continue
+		}
+		if !priorValue.Equals(plannedValue).True() {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+func isNullOrEmpty(v cty.Value) bool {
+	if v.IsNull() {
+		return true
+	}
+	ty := v.Type()
+	if ty.IsListType() || ty.IsSetType() || ty.IsMapType() {
+		return v.LengthInt() == 0
+	}
+	return false
+}
+
+// IgnoreChanges adds attributes to lifecycle.ignore_changes of the generated resource
+func (r *Resource) IgnoreChanges(attributes []string) {
+	if len(attributes) == 0 {
+		return
+	}
+	lifecycle, ok := r.Item["lifecycle"].(map[string]interface{})
+	if !ok {
+		lifecycle = map[string]interface{}{}
+		r.Item["lifecycle"] = lifecycle
+	}
+	ignored := map[string]bool{}
+	var ignoreChanges []interface{}
+	if current, ok := lifecycle["ignore_changes"].([]interface{}); ok {
+		for _, attribute := range current {
+			ignored[strings.TrimPrefix(fmt.Sprint(attribute), expressionPrefix)] = true
+			ignoreChanges = append(ignoreChanges, attribute)
+		}
+	}
+	for _, attribute := range attributes {
+		if !ignored[attribute] {
+			ignored[attribute] = true
+			ignoreChanges = append(ignoreChanges, Expression(attribute))
+		}
+	}
+	lifecycle["ignore_changes"] = ignoreChanges
+}
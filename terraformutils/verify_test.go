@@ -0,0 +1,83 @@
+// Copyright 2022 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestChangedAttributes(t *testing.T) {
+	prior := cty.ObjectVal(map[string]cty.Value{
+		"name":  cty.StringVal("web"),
+		"arn":   cty.StringVal("arn:1"),
+		"port":  cty.NumberIntVal(80),
+		"vpc":   cty.StringVal("vpc-1"),
+		"tags":  cty.MapValEmpty(cty.String),
+		"cidrs": cty.SetVal([]cty.Value{cty.StringVal("10.0.0.0/8")}),
+		"rule":  cty.ListValEmpty(validateSchema.BlockTypes["rule"].Block.ImpliedType()),
+	})
+	planned := cty.ObjectVal(map[string]cty.Value{
+		"name":  cty.StringVal("web"),
+		"arn":   cty.StringVal("arn:2"),
+		"port":  cty.NumberIntVal(8080),
+		"vpc":   cty.UnknownVal(cty.String),
+		"tags":  cty.NullVal(cty.Map(cty.String)),
+		"cidrs": cty.SetValEmpty(cty.String),
+		"rule":  cty.NullVal(cty.List(validateSchema.BlockTypes["rule"].Block.ImpliedType())),
+	})
+	changed := changedAttributes(validateSchema, prior, planned)
+	if !reflect.DeepEqual(changed, []string{"cidrs", "port"}) {
+		t.Errorf("unexpected changed attributes %v", changed)
+	}
+}
+
+func TestIgnoreChanges(t *testing.T) {
+	r := NewSimpleResource("sg-1", "web", "aws_security_group", "aws", []string{})
+	r.Item = map[string]interface{}{"name": "web"}
+	r.IgnoreChanges([]string{"tags_all"})
+	r.IgnoreChanges([]string{"tags_all", "mirror"})
+
+	data, err := HclPrintResource([]Resource{r}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "lifecycle {") || !strings.Contains(string(data), "ignore_changes = [mirror, tags_all]") {
+		t.Errorf("unexpected lifecycle block %s", string(data))
+	}
+
+	data, err = HclPrintResource([]Resource{r}, map[string]interface{}{}, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), expressionPrefix) || !strings.Contains(string(data), `"tags_all"`) {
+		t.Errorf("unexpected JSON lifecycle block %s", string(data))
+	}
+}
+
+func TestIgnoreChangesOnlyInLifecycle(t *testing.T) {
+	r := NewSimpleResource("rule-1", "web", "example_rule", "example", []string{})
+	r.Item = map[string]interface{}{"ignore_changes": []interface{}{"name"}, "values": []interface{}{"a", "b"}}
+	data, err := HclPrintResource([]Resource{r}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `ignore_changes = ["name"]`) {
+		t.Errorf("attributes which aren't generated by --verify should stay quoted in %s", data)
+	}
+}
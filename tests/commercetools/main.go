@@ -45,13 +45,9 @@ func main() {
 	provider = &commercetools_terraforming.CommercetoolsProvider{
 		Provider: terraformutils.Provider{},
 	}
-	err := cmd.Import(provider, cmd.ImportOptions{
-		Resources:   services,
-		PathPattern: cmd.DefaultPathPattern,
-		PathOutput:  cmd.DefaultPathOutput,
-		State:       "local",
-		Connect:     true,
-	}, []string{clientID, clientScope, clientSecret, projectKey, baseURL, tokenURL})
+	options := cmd.NewImportOptions()
+	options.Resources = services
+	err := cmd.Import(provider, options, []string{clientID, clientScope, clientSecret, projectKey, baseURL, tokenURL})
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
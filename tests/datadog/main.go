@@ -70,16 +70,12 @@ func main() {
 	_ = os.RemoveAll("generated/")
 
 	// Import created resources with Terraformer
-	err = cmd.Import(provider, cmd.ImportOptions{
-		Resources:   terraformerServices,
-		PathPattern: "{output}/",
-		PathOutput:  cmd.DefaultPathOutput,
-		State:       "local",
-		Connect:     true,
-		Output:      "hcl",
-		Filter:      terraformerFilters,
-		Verbose:     true,
-	}, []string{cfg.Datadog.apiKey, cfg.Datadog.appKey, cfg.Datadog.apiURL})
+	options := cmd.NewImportOptions()
+	options.Resources = terraformerServices
+	options.PathPattern = "{output}/"
+	options.Filter = terraformerFilters
+	options.Verbose = true
+	err = cmd.Import(provider, options, []string{cfg.Datadog.apiKey, cfg.Datadog.appKey, cfg.Datadog.apiURL})
 	if err != nil {
 		handleFatalErr(cfg, err, "Error while importing resources")
 	}
@@ -60,14 +60,10 @@ func main() {
 	provider = &gcp_terraforming.GCPProvider{
 		Provider: terraformutils.Provider{},
 	}
-	err := cmd.Import(provider, cmd.ImportOptions{
-		Resources:   services,
-		PathPattern: cmd.DefaultPathPattern,
-		PathOutput:  cmd.DefaultPathOutput,
-		State:       "local",
-		Zone:        "europe-west1-a",
-		Connect:     true,
-	}, []string{zone})
+	options := cmd.NewImportOptions()
+	options.Resources = services
+	options.Zone = "europe-west1-a"
+	err := cmd.Import(provider, options, []string{zone})
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
@@ -40,13 +40,9 @@ func main() {
 	provider = &github_terraforming.GithubProvider{
 		Provider: terraformutils.Provider{},
 	}
-	err := cmd.Import(provider, cmd.ImportOptions{
-		Resources:   services,
-		PathPattern: cmd.DefaultPathPattern,
-		PathOutput:  cmd.DefaultPathOutput,
-		State:       "local",
-		Connect:     true,
-	}, []string{organization, token})
+	options := cmd.NewImportOptions()
+	options.Resources = services
+	err := cmd.Import(provider, options, []string{organization, token})
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
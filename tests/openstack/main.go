@@ -38,13 +38,9 @@ func main() {
 	provider = &openstack_terraforming.OpenStackProvider{
 		Provider: terraformutils.Provider{},
 	}
-	err := cmd.Import(provider, cmd.ImportOptions{
-		Resources:   services,
-		PathPattern: cmd.DefaultPathPattern,
-		PathOutput:  cmd.DefaultPathOutput,
-		State:       "local",
-		Connect:     true,
-	}, []string{region})
+	options := cmd.NewImportOptions()
+	options.Resources = services
+	err := cmd.Import(provider, options, []string{region})
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
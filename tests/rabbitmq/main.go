@@ -42,13 +42,9 @@ func main() {
 	provider = &rabbitmq_terraforming.RBTProvider{
 		Provider: terraformutils.Provider{},
 	}
-	err := cmd.Import(provider, cmd.ImportOptions{
-		Resources:   services,
-		PathPattern: cmd.DefaultPathPattern,
-		PathOutput:  cmd.DefaultPathOutput,
-		State:       "local",
-		Connect:     true,
-	}, []string{endpoint, username, password})
+	options := cmd.NewImportOptions()
+	options.Resources = services
+	err := cmd.Import(provider, options, []string{endpoint, username, password})
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)